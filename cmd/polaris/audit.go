@@ -49,27 +49,66 @@ var (
 	auditNamespace      string
 	severityLevel       string
 	skipSslValidation   bool
+	baselineFile        string
+	writeBaselineFile   string
+	reportStale         bool
 )
 
 func init() {
 	rootCmd.AddCommand(auditCmd)
+	addAuditConfigFlags(auditCmd)
 	auditCmd.PersistentFlags().StringVar(&auditPath, "audit-path", "", "If specified, audits one or more YAML files instead of a cluster.")
 	auditCmd.PersistentFlags().BoolVar(&setExitCode, "set-exit-code-on-danger", false, "Set an exit code of 3 when the audit contains danger-level issues.")
 	auditCmd.PersistentFlags().BoolVar(&onlyShowFailedTests, "only-show-failed-tests", false, "If specified, audit output will only show failed tests.")
 	auditCmd.PersistentFlags().IntVar(&minScore, "set-exit-code-below-score", 0, "Set an exit code of 4 when the score is below this threshold (1-100).")
 	auditCmd.PersistentFlags().StringVar(&auditOutputURL, "output-url", "", "Destination URL to send audit results.")
 	auditCmd.PersistentFlags().StringVar(&auditOutputFile, "output-file", "", "Destination file for audit results.")
-	auditCmd.PersistentFlags().StringVarP(&auditOutputFormat, "format", "f", "json", "Output format for results - json, yaml, pretty, or score.")
+	auditCmd.PersistentFlags().StringVarP(&auditOutputFormat, "format", "f", "json", "Output format for results - json, yaml, pretty, sarif, junit, or score.")
 	auditCmd.PersistentFlags().BoolVar(&useColor, "color", true, "Whether to use color in pretty format.")
-	auditCmd.PersistentFlags().StringVar(&displayName, "display-name", "", "An optional identifier for the audit.")
 	auditCmd.PersistentFlags().StringVar(&resourceToAudit, "resource", "", "Audit a specific resource, in the format namespace/kind/version/name, e.g. nginx-ingress/Deployment.apps/v1/default-backend.")
 	auditCmd.PersistentFlags().StringVar(&helmChart, "helm-chart", "", "Will fill out Helm template")
 	auditCmd.PersistentFlags().StringSliceVar(&helmValues, "helm-values", []string{}, "Optional flag to add helm values")
 	auditCmd.PersistentFlags().BoolVar(&helmSkipTests, "helm-skip-tests", false, "Corresponds to --skip-tests of helm template")
-	auditCmd.PersistentFlags().StringSliceVar(&checks, "checks", []string{}, "Optional flag to specify specific checks to check")
-	auditCmd.PersistentFlags().StringVar(&auditNamespace, "namespace", "", "Namespace to audit. Only applies to in-cluster audits")
 	auditCmd.PersistentFlags().StringVar(&severityLevel, "severity", "", "Severity level used to filter results. Behaves like log levels. 'danger' is the least verbose (warning, danger)")
 	auditCmd.PersistentFlags().BoolVar(&skipSslValidation, "skip-ssl-validation", false, "Skip https certificate verification")
+	auditCmd.PersistentFlags().StringVar(&baselineFile, "baseline", "", "Baseline file of known failures to leave out of results, scores and exit codes.")
+	auditCmd.PersistentFlags().StringVar(&writeBaselineFile, "write-baseline", "", "Write a baseline file containing every failure in this audit.")
+}
+
+// addAuditConfigFlags adds the audit flags that change the configuration, which config print also takes
+func addAuditConfigFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&displayName, "display-name", "", "An optional identifier for the audit.")
+	cmd.PersistentFlags().StringSliceVar(&checks, "checks", []string{}, "Optional flag to specify specific checks to check")
+	cmd.PersistentFlags().StringVar(&auditNamespace, "namespace", "", "Namespace to audit. Only applies to in-cluster audits")
+	cmd.PersistentFlags().BoolVar(&reportStale, "report-stale-exemptions", false, "Run exempted checks, and report exemptions that didn't keep any failing check from running.")
+}
+
+// applyAuditFlags applies the audit flags that change the configuration
+func applyAuditFlags() {
+	if displayName != "" {
+		config.DisplayName = displayName
+		configSources.SetFlag("displayName", "display-name")
+	}
+	if reportStale {
+		config.ReportStaleExemptions = true
+		configSources.SetFlag("reportStaleExemptions", "report-stale-exemptions")
+	}
+	if len(checks) > 0 {
+		targetChecks := make(map[string]bool)
+		for _, check := range checks {
+			targetChecks[check] = true
+		}
+		for key := range config.Checks {
+			if isTarget := targetChecks[key]; !isTarget {
+				config.Checks[key] = cfg.SeverityIgnore
+				configSources.SetFlag("checks."+key, "checks")
+			}
+		}
+	}
+	if auditNamespace != "" {
+		config.Namespace = auditNamespace
+		configSources.SetFlag("namespace", "namespace")
+	}
 }
 
 var auditCmd = &cobra.Command{
@@ -77,20 +116,6 @@ var auditCmd = &cobra.Command{
 	Short: "Runs a one-time audit.",
 	Long:  `Runs a one-time audit.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if displayName != "" {
-			config.DisplayName = displayName
-		}
-		if len(checks) > 0 {
-			targetChecks := make(map[string]bool)
-			for _, check := range checks {
-				targetChecks[check] = true
-			}
-			for key := range config.Checks {
-				if isTarget := targetChecks[key]; !isTarget {
-					config.Checks[key] = cfg.SeverityIgnore
-				}
-			}
-		}
 		if auditNamespace != "" {
 			if helmChart != "" {
 				logrus.Warn("--namespace and --helm-chart are mutually exclusive. --namespace will be ignored.")
@@ -98,8 +123,8 @@ var auditCmd = &cobra.Command{
 			if auditPath != "" {
 				logrus.Warn("--namespace and --audit-path are mutually exclusive. --namespace will be ignored.")
 			}
-			config.Namespace = auditNamespace
 		}
+		applyAuditFlags()
 		if helmChart != "" {
 			var err error
 			auditPath, err = ProcessHelmTemplates(helmChart, helmValues, helmSkipTests)
@@ -122,6 +147,24 @@ var auditCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		if writeBaselineFile != "" {
+			if err := auditData.GetBaseline().WriteToFile(writeBaselineFile); err != nil {
+				logrus.Errorf("Error writing baseline to %s: %v", writeBaselineFile, err)
+				os.Exit(1)
+			}
+		}
+		if baselineFile != "" {
+			baseline, err := validator.ReadBaselineFromFile(baselineFile)
+			if err != nil {
+				logrus.Errorf("Error reading baseline: %v", err)
+				os.Exit(1)
+			}
+			auditData = auditData.ApplyBaseline(baseline)
+			for _, entry := range auditData.FixedBaselineEntries {
+				logrus.Infof("Baseline entry is no longer failing and can be removed: %s", entry)
+			}
+		}
+
 		outputAudit(auditData, auditOutputFile, auditOutputURL, auditOutputFormat, useColor, onlyShowFailedTests, severityLevel)
 
 		summary := auditData.GetSummary()
@@ -199,6 +242,10 @@ func outputAudit(auditData validator.AuditData, outputFile, outputURL, outputFor
 		}
 	} else if outputFormat == "pretty" {
 		outputBytes = []byte(auditData.GetPrettyOutput(useColor))
+	} else if outputFormat == "sarif" {
+		outputBytes, err = auditData.GetSARIFOutput(config, version)
+	} else if outputFormat == "junit" {
+		outputBytes, err = auditData.GetJUnitOutput()
 	} else {
 		outputBytes, err = json.MarshalIndent(auditData, "", "  ")
 	}
@@ -219,6 +266,10 @@ func outputAudit(auditData validator.AuditData, outputFile, outputURL, outputFor
 
 			if outputFormat == "json" {
 				req.Header.Set("Content-Type", "application/json")
+			} else if outputFormat == "sarif" {
+				req.Header.Set("Content-Type", "application/sarif+json")
+			} else if outputFormat == "junit" {
+				req.Header.Set("Content-Type", "application/xml")
 			} else if outputFormat == "yaml" {
 				req.Header.Set("Content-Type", "application/x-yaml")
 			} else {
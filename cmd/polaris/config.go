@@ -0,0 +1,140 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configPrintFormat string
+	showConfigSources bool
+)
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configPrintCmd)
+	rootCmd.AddCommand(configCmd)
+	addAuditConfigFlags(configPrintCmd)
+	configPrintCmd.PersistentFlags().StringVarP(&configPrintFormat, "format", "f", "yaml", "Output format for the configuration - yaml or json.")
+	configPrintCmd.PersistentFlags().BoolVar(&showConfigSources, "show-sources", false, "Show where each setting came from - the default config, a file, a URL or a flag.")
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with Polaris configuration files.",
+	Long:  `Work with Polaris configuration files.`,
+	// Config files are the subject of these commands, so they aren't loaded up front
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		setLogLevel()
+		// Files passed to a sub-command are the ones that may need credentials
+		if len(args) > 0 {
+			setRemoteConfigOptions(args)
+		} else {
+			setRemoteConfigOptions(configPaths)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		logrus.Error("You must specify a sub-command.")
+		err := cmd.Help()
+		if err != nil {
+			logrus.Error(err)
+		}
+		os.Exit(1)
+	},
+	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [files...]",
+	Short: "Validate Polaris configuration files.",
+	Long:  `Validate Polaris configuration files against the config schema, rejecting unknown fields, invalid severities and unknown checks. Defaults to the files passed with --config.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		locations := args
+		if len(locations) == 0 {
+			locations = configPaths
+		}
+		if len(locations) == 0 {
+			logrus.Error("Please specify the config files to validate")
+			os.Exit(1)
+		}
+		valid := true
+		for _, location := range locations {
+			if err := conf.ValidateFile(context.Background(), location); err != nil {
+				fmt.Fprintf(os.Stderr, "%s is invalid:\n%v\n", location, err)
+				valid = false
+				continue
+			}
+			fmt.Printf("%s is valid\n", location)
+		}
+		if !valid {
+			os.Exit(1)
+		}
+	},
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Prints the effective configuration.",
+	Long:  `Prints the configuration an audit would run with, after merging config files and applying flags.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig(cmd)
+		applyAuditFlags()
+		configHash, err := config.Hash()
+		if err != nil {
+			logrus.Errorf("Error hashing config: %v", err)
+			os.Exit(1)
+		}
+
+		var output []byte
+		switch configPrintFormat {
+		case "yaml":
+			sources := configSources
+			if !showConfigSources {
+				sources = nil
+			}
+			output, err = conf.MarshalYAMLWithSources(config, sources)
+			output = append([]byte(fmt.Sprintf("# hash: %s\n", configHash)), output...)
+		case "json":
+			if showConfigSources {
+				output, err = json.MarshalIndent(map[string]any{
+					"hash":    configHash,
+					"config":  config,
+					"sources": configSources,
+				}, "", "  ")
+			} else {
+				output, err = json.MarshalIndent(config, "", "  ")
+			}
+			output = append(output, '\n')
+		default:
+			logrus.Errorf("Unsupported output format %s", configPrintFormat)
+			os.Exit(1)
+		}
+		if err != nil {
+			logrus.Errorf("Error marshalling config: %v", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(output)
+	},
+}
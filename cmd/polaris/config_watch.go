@@ -0,0 +1,86 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/kube"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configMapRef     string
+	configMapKey     string
+	polarisConfigRef string
+)
+
+// addConfigWatchFlags adds the flags for servers to load their configuration from the cluster
+func addConfigWatchFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&configMapRef, "config-configmap", "", "Load the configuration from a ConfigMap, as namespace/name, and reload it when the ConfigMap changes.")
+	cmd.PersistentFlags().StringVar(&configMapKey, "config-configmap-key", "config.yaml", "Key of the ConfigMap that holds the configuration.")
+	cmd.PersistentFlags().StringVar(&polarisConfigRef, "config-polarisconfig", "", "Load the configuration from a PolarisConfig resource, as namespace/name, and reload it when the resource changes.")
+}
+
+// getConfigStore returns a store holding the configuration. If a ConfigMap or PolarisConfig was passed,
+// it's watched until ctx is cancelled, and each valid version replaces the configuration in the store.
+func getConfigStore(ctx context.Context) (*conf.Store, error) {
+	store := conf.NewStore(config)
+	if configMapRef == "" && polarisConfigRef == "" {
+		return store, nil
+	}
+	if configMapRef != "" && polarisConfigRef != "" {
+		return nil, fmt.Errorf("--config-configmap and --config-polarisconfig are mutually exclusive")
+	}
+
+	dynamicClient, _, _, _, err := kube.GetKubeClient(ctx, kubeContext)
+	if err != nil {
+		return nil, err
+	}
+	parse := func(rawBytes []byte) (conf.Configuration, error) {
+		c, err := conf.MergeConfigAndParse(rawBytes, checksDirs, mergeConfig)
+		if err != nil {
+			return c, err
+		}
+		applyConfigFlags(&c, conf.ConfigSources{})
+		if displayName != "" {
+			c.DisplayName = displayName
+		}
+		return c, nil
+	}
+	if configMapRef != "" {
+		namespace, name, err := parseNamespacedName(configMapRef)
+		if err != nil {
+			return nil, fmt.Errorf("--config-configmap: %w", err)
+		}
+		return store, kube.WatchConfigMap(ctx, dynamicClient, namespace, name, configMapKey, store, parse)
+	}
+	namespace, name, err := parseNamespacedName(polarisConfigRef)
+	if err != nil {
+		return nil, fmt.Errorf("--config-polarisconfig: %w", err)
+	}
+	return store, kube.WatchPolarisConfig(ctx, dynamicClient, namespace, name, store, parse)
+}
+
+func parseNamespacedName(ref string) (string, string, error) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("expected namespace/name, got %q", ref)
+	}
+	return namespace, name, nil
+}
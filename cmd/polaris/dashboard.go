@@ -20,7 +20,9 @@ import (
 	"net/http"
 
 	"github.com/fairwindsops/polaris/pkg/dashboard"
+	"github.com/fairwindsops/polaris/pkg/kube"
 	"github.com/fairwindsops/polaris/pkg/validator"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -29,6 +31,7 @@ var serverPort int
 var basePath string
 var loadAuditFile string
 var listeningAddress string
+var watchResources bool
 
 func init() {
 	rootCmd.AddCommand(dashboardCmd)
@@ -38,6 +41,8 @@ func init() {
 	dashboardCmd.PersistentFlags().StringVar(&loadAuditFile, "load-audit-file", "", "Runs the dashboard with data saved from a past audit.")
 	dashboardCmd.PersistentFlags().StringVar(&auditPath, "audit-path", "", "If specified, audits one or more YAML files instead of a cluster.")
 	dashboardCmd.PersistentFlags().StringVar(&displayName, "display-name", "", "An optional identifier for the audit.")
+	dashboardCmd.PersistentFlags().BoolVar(&watchResources, "watch", false, "Watch cluster resources and only re-validate the ones that changed, instead of listing everything on each request.")
+	addConfigWatchFlags(dashboardCmd)
 
 }
 
@@ -50,12 +55,30 @@ var dashboardCmd = &cobra.Command{
 			config.DisplayName = displayName
 		}
 
-		var auditDataPtr *validator.AuditData
-		if loadAuditFile != "" {
-			auditData := validator.ReadAuditFromFile(loadAuditFile)
-			auditDataPtr = &auditData
+		store, err := getConfigStore(context.Background())
+		if err != nil {
+			logrus.Fatalf("error loading config: %v", err)
+		}
+
+		var router *mux.Router
+		if watchResources {
+			if loadAuditFile != "" || auditPath != "" {
+				logrus.Fatalf("--watch can only be used when auditing a cluster")
+			}
+			var provider *kube.InformerResourceProvider
+			provider, err = kube.NewInformerResourceProviderFromCluster(context.Background(), config)
+			if err != nil {
+				logrus.Fatalf("error watching Kubernetes resources: %v", err)
+			}
+			router, err = dashboard.GetCachedRouter(context.Background(), store, provider, basePath)
+		} else {
+			var auditDataPtr *validator.AuditData
+			if loadAuditFile != "" {
+				auditData := validator.ReadAuditFromFile(loadAuditFile)
+				auditDataPtr = &auditData
+			}
+			router, err = dashboard.GetRouter(context.Background(), store, auditPath, serverPort, basePath, auditDataPtr)
 		}
-		router, err := dashboard.GetRouter(context.Background(), config, auditPath, serverPort, basePath, auditDataPtr)
 		if err != nil {
 			logrus.Fatalf("error creating router: %v", err)
 		}
@@ -0,0 +1,81 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/fairwindsops/polaris/pkg/validator"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffOutputFormat        string
+	diffOutputFile          string
+	diffUseColor            bool
+	setExitCodeOnRegression bool
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.PersistentFlags().StringVarP(&diffOutputFormat, "format", "f", "pretty", "Output format for the diff - pretty, json, or markdown.")
+	diffCmd.PersistentFlags().StringVar(&diffOutputFile, "output-file", "", "Destination file for the diff.")
+	diffCmd.PersistentFlags().BoolVar(&diffUseColor, "color", true, "Whether to use color in pretty format.")
+	diffCmd.PersistentFlags().BoolVar(&setExitCodeOnRegression, "set-exit-code-on-regression", false, "Set an exit code of 3 when the newer audit has new failures or a lower score.")
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff OLD_AUDIT NEW_AUDIT",
+	Short: "Compares two audit reports.",
+	Long:  `Compares two audit reports saved in JSON or YAML format, showing new and resolved failures, added and removed resources, and score changes.`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		before := validator.ReadAuditFromFile(args[0])
+		after := validator.ReadAuditFromFile(args[1])
+		diff := validator.DiffAudits(before, after)
+
+		var outputBytes []byte
+		var err error
+		switch diffOutputFormat {
+		case "pretty":
+			outputBytes = []byte(diff.GetPrettyOutput(diffUseColor))
+		case "markdown":
+			outputBytes = []byte(diff.GetMarkdownOutput())
+		case "json":
+			outputBytes, err = json.MarshalIndent(diff, "", "  ")
+		default:
+			logrus.Errorf("Unknown diff format %s", diffOutputFormat)
+			os.Exit(1)
+		}
+		if err != nil {
+			logrus.Errorf("Error marshalling diff: %v", err)
+			os.Exit(1)
+		}
+
+		if diffOutputFile == "" {
+			os.Stdout.Write(outputBytes)
+		} else if err := os.WriteFile(diffOutputFile, outputBytes, 0644); err != nil {
+			logrus.Errorf("Error writing diff to %s: %v", diffOutputFile, err)
+			os.Exit(1)
+		}
+
+		if setExitCodeOnRegression && diff.HasRegressions() {
+			logrus.Infof("%d new failures found, score changed by %d", len(diff.NewFailures), diff.ScoreDelta)
+			os.Exit(3)
+		}
+	},
+}
@@ -15,8 +15,10 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	conf "github.com/fairwindsops/polaris/pkg/config"
 	"github.com/sirupsen/logrus"
@@ -25,7 +27,7 @@ import (
 
 var (
 	mergeConfig                  bool
-	configPath                   string
+	configPaths                  []string
 	disallowExemptions           bool
 	disallowConfigExemptions     bool
 	disallowAnnotationExemptions bool
@@ -35,6 +37,15 @@ var (
 	displayName                  string
 	kubeContext                  string
 	insightsHost                 string
+	parallelism                  int
+	includeExempted              bool
+	configBearerToken            string
+	configBasicAuth              string
+	configCAFile                 string
+	configTimeout                time.Duration
+	configRetries                int
+	configCacheDir               string
+	checksDirs                   []string
 )
 
 var (
@@ -44,39 +55,36 @@ var (
 func init() {
 	// Flags
 	rootCmd.PersistentFlags().BoolVarP(&mergeConfig, "merge-config", "m", false, "If true, custom configuration will be merged with default configuration instead of replacing it.")
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Location of Polaris configuration file.")
+	rootCmd.PersistentFlags().StringArrayVarP(&configPaths, "config", "c", []string{}, "Location of Polaris configuration file. Can be repeated to layer several files, later ones taking precedence.")
 	rootCmd.PersistentFlags().StringVarP(&kubeContext, "context", "x", "", "Set the kube context.")
 	rootCmd.PersistentFlags().BoolVarP(&disallowExemptions, "disallow-exemptions", "", false, "Disallow any configured exemption.")
 	rootCmd.PersistentFlags().BoolVarP(&disallowConfigExemptions, "disallow-config-exemptions", "", false, "Disallow exemptions set within the configuration file.")
 	rootCmd.PersistentFlags().BoolVarP(&disallowAnnotationExemptions, "disallow-annotation-exemptions", "", false, "Disallow any exemption defined as a controller annotation.")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "", logrus.InfoLevel.String(), "Logrus log level to be output (trace, debug, info, warning, error, fatal, panic).")
 	rootCmd.PersistentFlags().StringVar(&insightsHost, "insights-host", "https://insights.fairwinds.com", "Fairwinds Insights host URL")
+	rootCmd.PersistentFlags().BoolVar(&includeExempted, "include-exempted", false, "Include checks that were skipped because of an exemption in the results.")
+	rootCmd.PersistentFlags().IntVar(&parallelism, "parallelism", 0, "Number of resources to validate at once. Defaults to the number of CPUs.")
+	rootCmd.PersistentFlags().StringVar(&configBearerToken, "config-bearer-token", "", "Bearer token for fetching --config URLs over https. Defaults to $POLARIS_CONFIG_BEARER_TOKEN.")
+	rootCmd.PersistentFlags().StringVar(&configBasicAuth, "config-basic-auth", "", "Username and password for fetching --config URLs over https, as user:password. Defaults to $POLARIS_CONFIG_BASIC_AUTH.")
+	rootCmd.PersistentFlags().StringVar(&configCAFile, "config-ca-file", "", "PEM bundle of certificate authorities to trust when fetching configs from URLs.")
+	rootCmd.PersistentFlags().DurationVar(&configTimeout, "config-timeout", 30*time.Second, "Timeout for each attempt to fetch a config from a URL.")
+	rootCmd.PersistentFlags().IntVar(&configRetries, "config-retries", 2, "How many times to retry fetching a config from a URL after a network or server error.")
+	rootCmd.PersistentFlags().StringVar(&configCacheDir, "config-cache-dir", "", "Directory to cache configs fetched from URLs in, to revalidate them and use them if the URL can't be reached.")
+	rootCmd.PersistentFlags().StringArrayVar(&checksDirs, "checks-dir", []string{}, "Directory, tarball or tarball URL to load custom checks from. Can be repeated.")
 }
 
 var config conf.Configuration
 
+// configSources records where each setting in config came from
+var configSources conf.ConfigSources
+
 var rootCmd = &cobra.Command{
 	Use:   "polaris",
 	Short: "polaris",
 	Long:  `Validation of best practices in your Kubernetes clusters.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		parsedLevel, err := logrus.ParseLevel(logLevel)
-		if err != nil {
-			logrus.Errorf("log-level flag has invalid value %s", logLevel)
-		} else {
-			logrus.SetLevel(parsedLevel)
-		}
-
-		config, err = conf.MergeConfigAndParseFile(configPath, mergeConfig)
-		if err != nil {
-			logrus.Errorf("Error parsing config at %s: %v", configPath, err)
-			os.Exit(1)
-		}
-
-		config.DisallowExemptions = disallowExemptions
-		config.DisallowConfigExemptions = disallowConfigExemptions
-		config.DisallowAnnotationExemptions = disallowAnnotationExemptions
-		config.KubeContext = kubeContext
+		setLogLevel()
+		loadConfig(cmd)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		logrus.Error("You must specify a sub-command.")
@@ -93,6 +101,84 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+func setLogLevel() {
+	parsedLevel, err := logrus.ParseLevel(logLevel)
+	if err != nil {
+		logrus.Errorf("log-level flag has invalid value %s", logLevel)
+	} else {
+		logrus.SetLevel(parsedLevel)
+	}
+}
+
+// loadConfig loads the config files and applies the global flags that override them
+func loadConfig(cmd *cobra.Command) {
+	setRemoteConfigOptions(configPaths)
+	var err error
+	config, configSources, err = conf.MergeConfigAndParseFilesWithSources(configPaths, checksDirs, mergeConfig)
+	if err != nil {
+		logrus.Errorf("Error parsing config at %s: %v", strings.Join(configPaths, ", "), err)
+		os.Exit(1)
+	}
+	applyConfigFlags(&config, configSources)
+}
+
+// applyConfigFlags applies the global flags that override the configuration
+func applyConfigFlags(c *conf.Configuration, sources conf.ConfigSources) {
+	c.DisallowExemptions = disallowExemptions
+	c.DisallowConfigExemptions = disallowConfigExemptions
+	c.DisallowAnnotationExemptions = disallowAnnotationExemptions
+	c.KubeContext = kubeContext
+	// These flags always replace the config file's settings
+	sources.SetFlag("disallowExemptions", "disallow-exemptions")
+	sources.SetFlag("disallowConfigExemptions", "disallow-config-exemptions")
+	sources.SetFlag("disallowAnnotationExemptions", "disallow-annotation-exemptions")
+	sources.SetFlag("kubeContext", "context")
+	if includeExempted {
+		c.IncludeExempted = true
+		sources.SetFlag("includeExempted", "include-exempted")
+	}
+	if parallelism != 0 {
+		c.Parallelism = parallelism
+		sources.SetFlag("parallelism", "parallelism")
+	}
+	// --checks-dir locations are added to the end of checkPaths when the config is parsed
+	for idx := max(len(c.CheckPaths)-len(checksDirs), 0); idx < len(c.CheckPaths); idx++ {
+		sources.SetFlag(fmt.Sprintf("checkPaths[%d]", idx), "checks-dir")
+	}
+}
+
+// setRemoteConfigOptions sets how configs are fetched from URLs. Credentials can be passed through the
+// environment, to keep them out of the process list, and are only sent to the hosts of locations.
+func setRemoteConfigOptions(locations []string) {
+	options := conf.RemoteConfigOptions{
+		BearerToken:    configBearerToken,
+		CAFile:         configCAFile,
+		Timeout:        configTimeout,
+		Retries:        configRetries,
+		CacheDir:       configCacheDir,
+		CredentialURLs: locations,
+	}
+	if options.BearerToken == "" {
+		options.BearerToken = os.Getenv("POLARIS_CONFIG_BEARER_TOKEN")
+	}
+	basicAuth := configBasicAuth
+	if basicAuth == "" {
+		basicAuth = os.Getenv("POLARIS_CONFIG_BASIC_AUTH")
+	}
+	if basicAuth != "" {
+		var ok bool
+		options.Username, options.Password, ok = strings.Cut(basicAuth, ":")
+		if !ok {
+			logrus.Error("config-basic-auth must be formatted as user:password")
+			os.Exit(1)
+		}
+	}
+	if err := conf.SetRemoteConfigOptions(options); err != nil {
+		logrus.Errorf("Error setting up remote config fetching: %v", err)
+		os.Exit(1)
+	}
+}
+
 // Execute the stuff
 func Execute(VERSION string) {
 	version = VERSION
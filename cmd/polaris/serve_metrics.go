@@ -0,0 +1,119 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fairwindsops/polaris/pkg/kube"
+	"github.com/fairwindsops/polaris/pkg/metrics"
+	"github.com/fairwindsops/polaris/pkg/validator"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricsPort             int
+	metricsListeningAddress string
+	metricsInterval         time.Duration
+	metricsNamespace        string
+	metricsAuditPath        string
+	metricsWatch            bool
+)
+
+func init() {
+	rootCmd.AddCommand(serveMetricsCmd)
+	serveMetricsCmd.PersistentFlags().IntVarP(&metricsPort, "port", "p", 9090, "Port for the metrics webserver.")
+	serveMetricsCmd.PersistentFlags().StringVar(&metricsListeningAddress, "listening-address", "", "Listening Address for the metrics webserver.")
+	serveMetricsCmd.PersistentFlags().DurationVar(&metricsInterval, "interval", 5*time.Minute, "How often to re-run the audit.")
+	serveMetricsCmd.PersistentFlags().StringVar(&metricsAuditPath, "audit-path", "", "If specified, audits one or more YAML files instead of a cluster.")
+	serveMetricsCmd.PersistentFlags().StringVar(&metricsNamespace, "namespace", "", "Namespace to audit. Only applies to in-cluster audits")
+	serveMetricsCmd.PersistentFlags().BoolVar(&metricsWatch, "watch", false, "Watch cluster resources and only re-validate the ones that changed, instead of listing everything on each audit.")
+}
+
+var serveMetricsCmd = &cobra.Command{
+	Use:   "serve-metrics",
+	Short: "Runs audits on a schedule and serves the results as Prometheus metrics.",
+	Long:  `Runs audits on a schedule and serves the results of the latest one as Prometheus metrics on /metrics.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if metricsNamespace != "" {
+			config.Namespace = metricsNamespace
+		}
+		if metricsInterval <= 0 {
+			logrus.Fatalf("--interval must be positive, got %s", metricsInterval)
+		}
+
+		ctx := context.Background()
+		runAudit := runMetricsAudit
+		if metricsWatch {
+			if metricsAuditPath != "" {
+				logrus.Fatalf("--watch can only be used when auditing a cluster")
+			}
+			provider, err := kube.NewInformerResourceProviderFromCluster(ctx, config)
+			if err != nil {
+				logrus.Fatalf("Error watching Kubernetes resources: %v", err)
+			}
+			auditor := validator.NewIncrementalAuditor()
+			runAudit = func(ctx context.Context) (validator.AuditData, error) {
+				k, err := provider.GetResourceProvider()
+				if err != nil {
+					return validator.AuditData{}, fmt.Errorf("fetching Kubernetes resources: %w", err)
+				}
+				return auditor.RunAudit(ctx, config, k)
+			}
+		}
+
+		exporter := metrics.NewExporter()
+		go runScheduledAudits(ctx, exporter, runAudit)
+
+		http.Handle("/metrics", exporter.Handler())
+		http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("OK"))
+		})
+		logrus.Infof("Starting Polaris metrics server on port %d", metricsPort)
+		logrus.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%d", metricsListeningAddress, metricsPort), nil))
+	},
+}
+
+func runScheduledAudits(ctx context.Context, exporter *metrics.Exporter, runAudit func(context.Context) (validator.AuditData, error)) {
+	ticker := time.NewTicker(metricsInterval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if auditData, err := runAudit(ctx); err != nil {
+			logrus.Errorf("Error running audit: %v", err)
+			exporter.RecordError()
+		} else {
+			exporter.Update(auditData, time.Since(start))
+			logrus.Infof("Audit finished with a score of %d", auditData.Score)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func runMetricsAudit(ctx context.Context) (validator.AuditData, error) {
+	k, err := kube.CreateResourceProvider(ctx, metricsAuditPath, "", config)
+	if err != nil {
+		return validator.AuditData{}, fmt.Errorf("fetching Kubernetes resources: %w", err)
+	}
+	return validator.RunAudit(ctx, config, k)
+}
@@ -41,6 +41,7 @@ func init() {
 	webhookCmd.PersistentFlags().BoolVar(&enableValidations, "validate", true, "Enable the validating webhook to reject workloads with issues")
 	webhookCmd.PersistentFlags().BoolVar(&enableMutations, "mutate", false, "Enable the mutating webhook to modify workloads with issues")
 	webhookCmd.PersistentFlags().StringVar(&certDir, "cert-dir", "/opt/cert", "Directory in which tls certificate is located")
+	addConfigWatchFlags(webhookCmd)
 }
 
 var webhookCmd = &cobra.Command{
@@ -73,14 +74,20 @@ var webhookCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		ctx := signals.SetupSignalHandler()
+		store, err := getConfigStore(ctx)
+		if err != nil {
+			logrus.Errorf("Error loading config: %v", err)
+			os.Exit(1)
+		}
 		if enableValidations {
-			fwebhook.NewValidateWebhook(mgr, config)
+			fwebhook.NewValidateWebhook(mgr, store)
 		}
 		if enableMutations {
-			fwebhook.NewMutateWebhook(context.Background(), mgr, config)
+			fwebhook.NewMutateWebhook(context.Background(), mgr, store)
 		}
 		logrus.Infof("Polaris webhook server listening on port %d", webhookPort)
-		if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+		if err := mgr.Start(ctx); err != nil {
 			logrus.Errorf("Error starting manager: %v", err)
 			os.Exit(1)
 		}
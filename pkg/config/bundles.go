@@ -0,0 +1,350 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// checkBundleMetadataFile describes a bundle. It's optional, and isn't loaded as a check.
+const checkBundleMetadataFile = "bundle.yaml"
+
+// maxCheckFileSize limits the size of each check in a check bundle
+const maxCheckFileSize = 1 << 20
+
+// maxBundledWASMSize limits the size of each WebAssembly module in a check bundle tarball
+const maxBundledWASMSize = 16 << 20
+
+// maxCheckTarballSize limits the total size of the files read from a check bundle tarball
+var maxCheckTarballSize int64 = 64 << 20
+
+// CheckBundle is a set of custom checks loaded from a directory or a tarball, in the same format as the
+// built-in checks: one file per check, named after its ID, e.g. imageRegistry.yaml
+type CheckBundle struct {
+	// Location is the directory, tarball or URL the bundle was loaded from
+	Location string `json:"-"`
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	// Checks are the default severities of the bundle's checks, for checks the configuration doesn't set
+	// a severity for. Checks without a severity aren't run.
+	Checks map[string]Severity `json:"checks"`
+	// CheckIDs are the IDs of the checks in the bundle
+	CheckIDs []string `json:"-"`
+}
+
+// String describes the bundle, e.g. "bundle acme-security@1.2.0 (checks/acme)"
+func (bundle CheckBundle) String() string {
+	if bundle.Name == "" {
+		return "bundle " + bundle.Location
+	}
+	return fmt.Sprintf("bundle %s@%s (%s)", bundle.Name, bundle.Version, bundle.Location)
+}
+
+// loadedCheckBundle is a bundle that has been read, along with its checks
+type loadedCheckBundle struct {
+	bundle CheckBundle
+	checks map[string]SchemaCheck
+}
+
+// loadedCheckBundles keeps the bundles that have been read, keyed by their cleaned location, so that
+// reloading a config, e.g. when its ConfigMap changes, doesn't read its bundles again
+var loadedCheckBundles = struct {
+	sync.Mutex
+	bundles map[string]loadedCheckBundle
+}{bundles: map[string]loadedCheckBundle{}}
+
+// LoadCheckPaths loads the checks in directories, tarballs or tarball URLs, and adds them to the
+// configuration's custom checks. A check in the configuration's customChecks takes precedence over a
+// bundled check with the same ID, but bundled checks can't have the same ID as a built-in check or a
+// check in another bundle. A location that has already been loaded is skipped, and each location is only
+// read once per process.
+func (conf *Configuration) LoadCheckPaths(locations []string) ([]CheckBundle, error) {
+	var bundles []CheckBundle
+	for _, location := range locations {
+		if conf.hasCheckBundle(location) {
+			logrus.Debugf("Skipping %s, which has already been loaded", location)
+			continue
+		}
+		bundle, checks, err := loadCheckBundleOnce(location)
+		if err != nil {
+			return nil, fmt.Errorf("loading checks from %s: %w", location, err)
+		}
+		if err := conf.addCheckBundle(bundle, checks); err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles, nil
+}
+
+// hasCheckBundle returns true if the checks at a location have already been loaded
+func (conf *Configuration) hasCheckBundle(location string) bool {
+	return slices.ContainsFunc(conf.CheckBundles, func(bundle CheckBundle) bool {
+		return cleanCheckPath(bundle.Location) == cleanCheckPath(location)
+	})
+}
+
+// cleanCheckPath normalizes a local path, so the same directory is recognized however it's written
+func cleanCheckPath(location string) string {
+	if isConfigURL(location) {
+		return location
+	}
+	return filepath.Clean(location)
+}
+
+func (conf *Configuration) addCheckBundle(bundle CheckBundle, checks map[string]SchemaCheck) error {
+	if conf.CustomChecks == nil {
+		conf.CustomChecks = map[string]SchemaCheck{}
+	}
+	if conf.Checks == nil {
+		conf.Checks = map[string]Severity{}
+	}
+	for _, id := range bundle.CheckIDs {
+		if _, ok := BuiltInChecks[id]; ok {
+			return fmt.Errorf("check %s in %s conflicts with a built-in check", id, bundle)
+		}
+		for _, other := range conf.CheckBundles {
+			if slices.Contains(other.CheckIDs, id) {
+				return fmt.Errorf("check %s is in both %s and %s", id, other, bundle)
+			}
+		}
+	}
+	for _, id := range bundle.CheckIDs {
+		if _, ok := conf.CustomChecks[id]; ok {
+			logrus.Debugf("Using custom check %s from the config instead of %s", id, bundle)
+			continue
+		}
+		conf.CustomChecks[id] = checks[id]
+		if _, ok := conf.Checks[id]; !ok && bundle.Checks[id] != "" {
+			conf.Checks[id] = bundle.Checks[id]
+		}
+	}
+	conf.CheckBundles = append(conf.CheckBundles, bundle)
+	return nil
+}
+
+// loadCheckBundleOnce reads the checks in a directory or tarball, unless they've already been read
+func loadCheckBundleOnce(location string) (CheckBundle, map[string]SchemaCheck, error) {
+	loadedCheckBundles.Lock()
+	defer loadedCheckBundles.Unlock()
+	if loaded, ok := loadedCheckBundles.bundles[cleanCheckPath(location)]; ok {
+		// Keep the location as it was written, which sources and errors refer to
+		loaded.bundle.Location = location
+		return loaded.bundle, loaded.checks, nil
+	}
+	bundle, checks, err := loadCheckBundle(location)
+	if err != nil {
+		return bundle, nil, err
+	}
+	loadedCheckBundles.bundles[cleanCheckPath(location)] = loadedCheckBundle{bundle, checks}
+	return bundle, checks, nil
+}
+
+// loadCheckBundle reads the checks in a directory or tarball
+func loadCheckBundle(location string) (CheckBundle, map[string]SchemaCheck, error) {
+	var files, tarballFiles map[string][]byte
+	var err error
+	if isCheckTarball(location) {
+		var content []byte
+		if content, err = readConfigLocation(location); err == nil {
+			files, err = readCheckTarball(content)
+			tarballFiles = files
+		}
+	} else {
+		files, err = readCheckDirectory(location)
+	}
+	if err != nil {
+		return CheckBundle{}, nil, err
+	}
+
+	bundle := CheckBundle{Location: location}
+	if metadata, ok := files[checkBundleMetadataFile]; ok {
+		if err := UnmarshalYAMLOrJSON(metadata, &bundle); err != nil {
+			return bundle, nil, fmt.Errorf("%s: %w", checkBundleMetadataFile, err)
+		}
+		if bundle.Name == "" || bundle.Version == "" {
+			return bundle, nil, fmt.Errorf("%s must set a name and version", checkBundleMetadataFile)
+		}
+		delete(files, checkBundleMetadataFile)
+	}
+
+	checks := map[string]SchemaCheck{}
+	for name, content := range files {
+		if !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		id := strings.TrimSuffix(path.Base(name), ".yaml")
+		if _, ok := checks[id]; ok {
+			return bundle, nil, fmt.Errorf("check %s is defined more than once", id)
+		}
+		check, err := decodeCheck(content)
+		if err == nil {
+			err = resolveBundledWASM(&check, location, tarballFiles)
+		}
+		if err == nil {
+			err = check.Initialize(id)
+		}
+		if err != nil {
+			return bundle, nil, fmt.Errorf("check %s: %w", name, err)
+		}
+		checks[id] = check
+		bundle.CheckIDs = append(bundle.CheckIDs, id)
+	}
+	slices.Sort(bundle.CheckIDs)
+	var errs []error
+	for id, severity := range bundle.Checks {
+		if _, ok := checks[id]; !ok {
+			errs = append(errs, fmt.Errorf("%s sets a severity for check %s, which isn't in the bundle", checkBundleMetadataFile, id))
+		} else if !severity.isValid() {
+			errs = append(errs, fmt.Errorf("invalid severity %q for check %s", severity, id))
+		}
+	}
+	return bundle, checks, errors.Join(errs...)
+}
+
+func isCheckTarball(location string) bool {
+	return strings.HasSuffix(location, ".tar.gz") || strings.HasSuffix(location, ".tgz") ||
+		strings.Contains(location, ".tar.gz#") || strings.Contains(location, ".tgz#")
+}
+
+// readCheckDirectory returns the YAML files in a directory and its subdirectories, keyed by their path
+// within it
+func readCheckDirectory(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxCheckFileSize {
+			return fmt.Errorf("%s is larger than %d bytes", filePath, maxCheckFileSize)
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = content
+		return nil
+	})
+	return files, err
+}
+
+// readCheckTarball returns the YAML and WebAssembly files in a gzipped tarball, keyed by their path within
+// it. A single top-level directory, as in GitHub release archives, is stripped.
+func readCheckTarball(content []byte) (map[string][]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	files := map[string][]byte{}
+	var totalSize int64
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		maxSize := int64(maxCheckFileSize)
+		if strings.HasSuffix(header.Name, ".wasm") {
+			maxSize = maxBundledWASMSize
+		} else if !strings.HasSuffix(header.Name, ".yaml") {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > maxSize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", header.Name, maxSize)
+		}
+		if totalSize += header.Size; totalSize > maxCheckTarballSize {
+			return nil, fmt.Errorf("tarball is larger than %d bytes", maxCheckTarballSize)
+		}
+		fileContent, err := io.ReadAll(io.LimitReader(tarReader, maxSize))
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(strings.TrimPrefix(header.Name, "./"))] = fileContent
+	}
+	return stripTopLevelDirectory(files), nil
+}
+
+func stripTopLevelDirectory(files map[string][]byte) map[string][]byte {
+	var topLevel string
+	for name := range files {
+		dir, _, ok := strings.Cut(name, "/")
+		if !ok || (topLevel != "" && dir != topLevel) {
+			return files
+		}
+		topLevel = dir
+	}
+	stripped := map[string][]byte{}
+	for name, content := range files {
+		stripped[strings.TrimPrefix(name, topLevel+"/")] = content
+	}
+	return stripped
+}
+
+// resolveCheckPaths resolves a config's check paths relative to the config's own location, like the
+// configs it extends
+func resolveCheckPaths(location string, layer map[string]any) {
+	checkPaths, _ := layer["checkPaths"].([]any)
+	for idx, checkPath := range checkPaths {
+		if str, ok := checkPath.(string); ok && str != "" {
+			checkPaths[idx] = resolveConfigLocation(location, str)
+		}
+	}
+}
+
+// RecordCheckBundles records the bundles that custom checks, and the default severities of checks,
+// came from. Checks from the configuration aren't changed.
+func (sources ConfigSources) RecordCheckBundles(bundles []CheckBundle) {
+	for _, bundle := range bundles {
+		for _, id := range bundle.CheckIDs {
+			if _, ok := sources["customChecks."+id]; !ok {
+				sources["customChecks."+id] = bundle.String()
+			}
+			if _, ok := sources["checks."+id]; !ok && bundle.Checks[id] != "" {
+				sources["checks."+id] = bundle.String()
+			}
+		}
+	}
+}
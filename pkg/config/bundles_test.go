@@ -0,0 +1,215 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var bundledCheck = `
+successMessage: Image comes from an approved registry
+failureMessage: Image should come from an approved registry
+category: Security
+target: Container
+schema:
+  properties:
+    image:
+      pattern: ^quay.io
+`
+
+func writeCheckBundle(t *testing.T, dir string) {
+	writeConfigFile(t, filepath.Join(dir, "bundle.yaml"), "name: acme\nversion: 1.2.0\nchecks:\n  approvedRegistry: danger\n")
+	writeConfigFile(t, filepath.Join(dir, "security", "approvedRegistry.yaml"), bundledCheck)
+	writeConfigFile(t, filepath.Join(dir, "reliability", "pinnedTag.yaml"), "category: Reliability\ntarget: Container\nschema: {}\n")
+	writeConfigFile(t, filepath.Join(dir, "README.md"), "Not a check")
+}
+
+func TestCheckPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeCheckBundle(t, filepath.Join(dir, "checks"))
+	app := writeConfigFile(t, filepath.Join(dir, "app.yaml"), `
+checkPaths:
+- checks
+checks:
+  pinnedTag: warning
+`)
+
+	c, sources, err := MergeConfigAndParseFilesWithSources([]string{app}, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "checks")}, c.CheckPaths)
+	if assert.Len(t, c.CheckBundles, 1) {
+		assert.Equal(t, "acme", c.CheckBundles[0].Name)
+		assert.Equal(t, "1.2.0", c.CheckBundles[0].Version)
+		assert.Equal(t, []string{"approvedRegistry", "pinnedTag"}, c.CheckBundles[0].CheckIDs)
+	}
+	assert.Equal(t, "Image should come from an approved registry", c.CustomChecks["approvedRegistry"].FailureMessage)
+	assert.Equal(t, "approvedRegistry", c.CustomChecks["approvedRegistry"].ID)
+	// The bundle's default severities are used unless the config sets them
+	assert.Equal(t, SeverityDanger, c.Checks["approvedRegistry"])
+	assert.Equal(t, SeverityWarning, c.Checks["pinnedTag"])
+
+	bundle := "bundle acme@1.2.0 (" + filepath.Join(dir, "checks") + ")"
+	assert.Equal(t, bundle, sources["customChecks.approvedRegistry"])
+	assert.Equal(t, bundle, sources["checks.approvedRegistry"])
+	assert.Equal(t, "file "+app, sources["checks.pinnedTag"])
+}
+
+func TestCheckPathsArgument(t *testing.T) {
+	dir := t.TempDir()
+	checks := filepath.Join(dir, "checks")
+	writeCheckBundle(t, checks)
+	// The config can set the severity of a check that's only passed as an argument, e.g. --checks-dir
+	app := writeConfigFile(t, filepath.Join(dir, "app.yaml"), "checks:\n  pinnedTag: warning\n")
+
+	// The same bundle is only loaded once
+	c, sources, err := MergeConfigAndParseFilesWithSources([]string{app}, []string{checks, checks + "/"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{checks, checks + "/"}, c.CheckPaths)
+	assert.Len(t, c.CheckBundles, 1)
+	assert.Equal(t, SeverityWarning, c.Checks["pinnedTag"])
+	assert.Equal(t, "bundle acme@1.2.0 ("+checks+")", sources["customChecks.pinnedTag"])
+
+	c, err = MergeConfigAndParse([]byte("checks:\n  pinnedTag: warning\n"), []string{checks}, false)
+	assert.NoError(t, err)
+	assert.Len(t, c.CheckBundles, 1)
+	assert.Equal(t, SeverityWarning, c.Checks["pinnedTag"])
+}
+
+func TestCheckPathsOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeCheckBundle(t, dir)
+	c, err := MergeConfigAndParse([]byte(`
+checkPaths:
+- `+dir+`
+checks:
+  approvedRegistry: warning
+customChecks:
+  approvedRegistry:
+    failureMessage: Overridden
+    target: Container
+`), nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Overridden", c.CustomChecks["approvedRegistry"].FailureMessage)
+	assert.Equal(t, SeverityWarning, c.Checks["approvedRegistry"])
+}
+
+func TestCheckPathsConflicts(t *testing.T) {
+	dir := t.TempDir()
+	writeCheckBundle(t, filepath.Join(dir, "acme"))
+	writeCheckBundle(t, filepath.Join(dir, "copy"))
+	_, err := MergeConfigAndParse([]byte("checkPaths:\n- "+filepath.Join(dir, "acme")+"\n- "+filepath.Join(dir, "copy")+"\n"), nil, false)
+	assert.EqualError(t, err, "check approvedRegistry is in both bundle acme@1.2.0 ("+filepath.Join(dir, "acme")+") and bundle acme@1.2.0 ("+filepath.Join(dir, "copy")+")")
+
+	builtIn := filepath.Join(dir, "builtin")
+	writeConfigFile(t, filepath.Join(builtIn, "hostIPCSet.yaml"), bundledCheck)
+	_, err = MergeConfigAndParse([]byte("checkPaths:\n- "+builtIn+"\n"), nil, false)
+	assert.EqualError(t, err, "check hostIPCSet in bundle "+builtIn+" conflicts with a built-in check")
+
+	invalid := filepath.Join(dir, "invalid")
+	writeConfigFile(t, filepath.Join(invalid, "bundle.yaml"), "name: invalid\n")
+	_, err = MergeConfigAndParse([]byte("checkPaths:\n- "+invalid+"\n"), nil, false)
+	assert.EqualError(t, err, "loading checks from "+invalid+": bundle.yaml must set a name and version")
+
+	writeConfigFile(t, filepath.Join(invalid, "bundle.yaml"), "name: invalid\nversion: 1.0.0\nchecks:\n  missing: warning\n")
+	_, err = MergeConfigAndParse([]byte("checkPaths:\n- "+invalid+"\n"), nil, false)
+	assert.EqualError(t, err, "loading checks from "+invalid+": bundle.yaml sets a severity for check missing, which isn't in the bundle")
+
+	// Bundled checks are decoded as strictly as custom checks in a config
+	typo := filepath.Join(dir, "typo")
+	writeConfigFile(t, filepath.Join(typo, "legacy.yaml"), "target: Container\njsonSchema: '{}'\n")
+	_, err = MergeConfigAndParse([]byte("checkPaths:\n- "+typo+"\n"), nil, false)
+	assert.EqualError(t, err, "loading checks from "+typo+`: check legacy.yaml: Decoding schema check failed: json: unknown field "jsonSchema"`)
+}
+
+// buildCheckTarball returns a gzipped tarball of files, keyed by their path within it
+func buildCheckTarball(t *testing.T, files map[string]string) []byte {
+	var tarball bytes.Buffer
+	gzipWriter := gzip.NewWriter(&tarball)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		assert.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tarWriter.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tarWriter.Close())
+	assert.NoError(t, gzipWriter.Close())
+	return tarball.Bytes()
+}
+
+func TestCheckPathsTarball(t *testing.T) {
+	tarball := buildCheckTarball(t, map[string]string{
+		"acme-1.2.0/bundle.yaml":                    "name: acme\nversion: 1.2.0\n",
+		"acme-1.2.0/security/approvedRegistry.yaml": bundledCheck,
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tarball)
+	}))
+	defer srv.Close()
+
+	c, err := MergeConfigAndParse([]byte("checkPaths:\n- "+srv.URL+"/acme.tar.gz\nchecks:\n  approvedRegistry: warning\n"), nil, false)
+	assert.NoError(t, err)
+	if assert.Len(t, c.CheckBundles, 1) {
+		assert.Equal(t, "bundle acme@1.2.0 ("+srv.URL+"/acme.tar.gz)", c.CheckBundles[0].String())
+	}
+	assert.Equal(t, "Security", c.CustomChecks["approvedRegistry"].Category)
+
+	_, err = MergeConfigAndParse([]byte("checkPaths:\n- "+srv.URL+"/acme.tar.gz#sha256="+"0000000000000000000000000000000000000000000000000000000000000000"+"\n"), nil, false)
+	assert.ErrorContains(t, err, "has sha256")
+}
+
+func TestCheckPathsLoadedOnce(t *testing.T) {
+	tarball := buildCheckTarball(t, map[string]string{"approvedRegistry.yaml": bundledCheck})
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(tarball)
+	}))
+	defer srv.Close()
+	raw := []byte("checkPaths:\n- " + srv.URL + "/acme.tar.gz\nchecks:\n  approvedRegistry: warning\n")
+
+	// Parse doesn't read checkPaths
+	c, err := Parse(raw)
+	assert.NoError(t, err)
+	assert.Empty(t, c.CheckBundles)
+	assert.Equal(t, 0, requests)
+
+	// and a bundle is only read the first time a config that uses it is loaded
+	for i := 0; i < 2; i++ {
+		c, err = MergeConfigAndParse(raw, nil, false)
+		assert.NoError(t, err)
+		assert.Len(t, c.CheckBundles, 1)
+		assert.Equal(t, "Security", c.CustomChecks["approvedRegistry"].Category)
+	}
+	assert.Equal(t, 1, requests)
+}
+
+func TestCheckTarballSizeLimit(t *testing.T) {
+	defer func(limit int64) { maxCheckTarballSize = limit }(maxCheckTarballSize)
+	maxCheckTarballSize = int64(len(bundledCheck)) * 2
+
+	_, err := readCheckTarball(buildCheckTarball(t, map[string]string{"a.yaml": bundledCheck, "b.yaml": bundledCheck}))
+	assert.NoError(t, err)
+	_, err = readCheckTarball(buildCheckTarball(t, map[string]string{"a.yaml": bundledCheck, "b.yaml": bundledCheck, "c.yaml": bundledCheck}))
+	assert.EqualError(t, err, fmt.Sprintf("tarball is larger than %d bytes", maxCheckTarballSize))
+}
@@ -0,0 +1,137 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// celCostLimit bounds how much work a single CEL expression can do, so a check can't hang an audit or webhook
+const celCostLimit = 1000000
+
+// celProgram is a compiled CEL expression
+type celProgram struct {
+	program cel.Program
+}
+
+var celEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("podSpec", cel.DynType),
+		cel.Variable("podTemplate", cel.DynType),
+		cel.Variable("container", cel.DynType),
+		cel.Variable("namespaceObject", cel.DynType),
+		cel.Variable("related", cel.MapType(cel.StringType, cel.ListType(cel.DynType))),
+		ext.Strings(),
+		ext.Lists(),
+		ext.Sets(),
+	)
+})
+
+// compileCEL compiles an expression, which must return the given type
+func compileCEL(expression string, outputType *cel.Type) (*celProgram, error) {
+	env, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if !ast.OutputType().IsExactType(outputType) && !ast.OutputType().IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression must return a %s, not a %s", outputType, ast.OutputType())
+	}
+	program, err := env.Program(ast, cel.CostLimit(celCostLimit))
+	if err != nil {
+		return nil, err
+	}
+	return &celProgram{program: program}, nil
+}
+
+func (p *celProgram) eval(input CheckInput) (any, error) {
+	activation := input.variables()
+	out, _, err := p.program.Eval(activation)
+	if err != nil {
+		return nil, err
+	}
+	return out.Value(), nil
+}
+
+// initializeCEL compiles the check's CEL expressions, if it has any
+func (check *SchemaCheck) initializeCEL() error {
+	check.celProgram = nil
+	check.celMessageProgram = nil
+	if check.CEL == "" {
+		if check.CELMessage != "" {
+			return fmt.Errorf("check %s: celMessage requires cel", check.ID)
+		}
+		return nil
+	}
+	var err error
+	check.celProgram, err = compileCEL(check.CEL, cel.BoolType)
+	if err != nil {
+		return fmt.Errorf("check %s: compiling cel: %w", check.ID, err)
+	}
+	if check.CELMessage != "" {
+		check.celMessageProgram, err = compileCEL(check.CELMessage, cel.StringType)
+		if err != nil {
+			return fmt.Errorf("check %s: compiling celMessage: %w", check.ID, err)
+		}
+	}
+	return nil
+}
+
+// HasCEL returns true if the check has a CEL expression to evaluate
+func (check SchemaCheck) HasCEL() bool {
+	return check.CEL != ""
+}
+
+// CheckCEL evaluates the check's CEL expression. If it fails and the check has a celMessage, the
+// message it evaluates to is returned.
+func (check SchemaCheck) CheckCEL(input CheckInput) (bool, string, error) {
+	if check.celProgram == nil {
+		// The check wasn't initialized, e.g. because it was built in code
+		if err := check.initializeCEL(); err != nil {
+			return false, "", err
+		}
+		if check.celProgram == nil {
+			return true, "", nil
+		}
+	}
+	out, err := check.celProgram.eval(input)
+	if err != nil {
+		return false, "", fmt.Errorf("check %s: evaluating cel: %w", check.ID, err)
+	}
+	passes, ok := out.(bool)
+	if !ok {
+		return false, "", fmt.Errorf("check %s: cel returned %T, expected a bool", check.ID, out)
+	}
+	if passes || check.celMessageProgram == nil {
+		return passes, "", nil
+	}
+	out, err = check.celMessageProgram.eval(input)
+	if err != nil {
+		return false, "", fmt.Errorf("check %s: evaluating celMessage: %w", check.ID, err)
+	}
+	message, ok := out.(string)
+	if !ok {
+		return false, "", fmt.Errorf("check %s: celMessage returned %T, expected a string", check.ID, out)
+	}
+	return false, message, nil
+}
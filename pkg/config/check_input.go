@@ -0,0 +1,67 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// CheckInput holds what a check's CEL expressions, Rego policy and WebAssembly module are evaluated with.
+// Fields that don't apply to the check's target, e.g. Container for a PodSpec check, are null.
+type CheckInput struct {
+	// Object is the whole resource
+	Object map[string]any
+	// PodSpec and PodTemplate are set for controllers with pods
+	PodSpec     map[string]any
+	PodTemplate map[string]any
+	// Container is set for Container checks
+	Container map[string]any
+	// NamespaceObject is the resource's namespace. Only its name is set if the namespace wasn't loaded.
+	NamespaceObject map[string]any
+	// Related holds the resources in the same namespace (or cluster-scoped) of each kind listed in
+	// SchemaCheck.RelatedResources, keyed by kind
+	Related map[string][]any
+}
+
+// variables returns the input keyed by the names that expressions and policies refer to it with
+func (input CheckInput) variables() map[string]any {
+	related := input.Related
+	if related == nil {
+		related = map[string][]any{}
+	}
+	variables := map[string]any{
+		"object":          input.Object,
+		"podSpec":         input.PodSpec,
+		"podTemplate":     input.PodTemplate,
+		"container":       input.Container,
+		"namespaceObject": input.NamespaceObject,
+		"related":         related,
+	}
+	for name, value := range variables {
+		// Typed nil maps need to be converted to null
+		if m, ok := value.(map[string]any); ok && m == nil {
+			variables[name] = nil
+		}
+	}
+	return variables
+}
+
+// RelatedKinds returns the kinds of the other resources that the check looks at
+func (check SchemaCheck) RelatedKinds() []string {
+	kinds := append([]string{}, check.RelatedResources...)
+	for kind := range check.AdditionalSchemas {
+		kinds = append(kinds, kind)
+	}
+	for kind := range check.AdditionalSchemaStrings {
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
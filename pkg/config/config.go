@@ -17,13 +17,12 @@ package config
 import (
 	"bytes"
 	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
-	"os"
-	"strings"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
@@ -33,12 +32,22 @@ type Configuration struct {
 	Checks                       map[string]Severity    `json:"checks"`
 	CustomChecks                 map[string]SchemaCheck `json:"customChecks"`
 	Exemptions                   []Exemption            `json:"exemptions"`
+	SeverityOverrides            []SeverityOverride     `json:"severityOverrides,omitempty"`
 	DisallowExemptions           bool                   `json:"disallowExemptions"`
 	DisallowConfigExemptions     bool                   `json:"disallowConfigExemptions"`
 	DisallowAnnotationExemptions bool                   `json:"disallowAnnotationExemptions"`
+	IncludeExempted              bool                   `json:"includeExempted"`
+	ReportStaleExemptions        bool                   `json:"reportStaleExemptions"`
 	Mutations                    []string               `json:"mutations"`
 	KubeContext                  string                 `json:"kubeContext"`
 	Namespace                    string                 `json:"namespace"`
+	Parallelism                  int                    `json:"parallelism"`
+	// Extends lists the configs this one is layered on top of. It's resolved when config files are loaded.
+	Extends []string `json:"extends,omitempty"`
+	// CheckPaths are directories, tarballs or tarball URLs to load custom checks from
+	CheckPaths []string `json:"checkPaths,omitempty"`
+	// CheckBundles are the bundles that were loaded from CheckPaths
+	CheckBundles []CheckBundle `json:"-"`
 }
 
 // Exemption represents an exemption to normal rules
@@ -47,6 +56,23 @@ type Exemption struct {
 	ControllerNames []string `json:"controllerNames"`
 	ContainerNames  []string `json:"containerNames"`
 	Namespace       string   `json:"namespace"`
+	// Namespaces are glob patterns, e.g. team-*
+	Namespaces []string `json:"namespaces,omitempty"`
+	// NamespaceSelector matches the labels of the workload's namespace
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Selector matches the workload's labels
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// AnnotationSelector matches the workload's annotations
+	AnnotationSelector *metav1.LabelSelector `json:"annotationSelector,omitempty"`
+	Kinds              []string              `json:"kinds,omitempty"`
+	// ControllerNamePatterns and ContainerNamePatterns are regular expressions that must match the whole name
+	ControllerNamePatterns []string `json:"controllerNamePatterns,omitempty"`
+	ContainerNamePatterns  []string `json:"containerNamePatterns,omitempty"`
+	// Expires is the last day (2006-01-02) the exemption applies on, or an RFC 3339 time it stops applying at
+	Expires string `json:"expires,omitempty"`
+	Owner   string `json:"owner,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Ticket  string `json:"ticket,omitempty"`
 }
 
 //go:embed default.yaml
@@ -54,52 +80,137 @@ var defaultConfig []byte
 
 // MergeConfigAndParseFile parses config from a file.
 func MergeConfigAndParseFile(customConfigPath string, mergeConfig bool) (Configuration, error) {
-	rawBytes, err := mergeConfigFile(customConfigPath, mergeConfig)
+	if customConfigPath == "" {
+		return MergeConfigAndParseFiles(nil, mergeConfig)
+	}
+	return MergeConfigAndParseFiles([]string{customConfigPath}, mergeConfig)
+}
+
+// MergeConfigAndParseFiles parses config from one or more files or URLs, layered in order along with
+// the configs they extend. If mergeConfig is set, the result is merged on top of the default config.
+func MergeConfigAndParseFiles(customConfigPaths []string, mergeConfig bool) (Configuration, error) {
+	conf, _, err := MergeConfigAndParseFilesWithSources(customConfigPaths, nil, mergeConfig)
+	return conf, err
+}
+
+// MergeConfigAndParseFilesWithSources works like MergeConfigAndParseFiles, and also returns where each
+// setting came from. checkPaths, e.g. from the command line, are added to the config's checkPaths, so
+// their checks are loaded before the config is validated.
+func MergeConfigAndParseFilesWithSources(customConfigPaths, checkPaths []string, mergeConfig bool) (Configuration, ConfigSources, error) {
+	rawBytes, sources, err := mergeConfigFiles(customConfigPaths, mergeConfig)
+	if err != nil {
+		return Configuration{}, nil, err
+	}
+	rawBytes, err = appendCheckPaths(rawBytes, checkPaths)
 	if err != nil {
+		return Configuration{}, nil, err
+	}
+
+	conf, err := parseAndLoadCheckPaths(rawBytes)
+	if err != nil {
+		return conf, sources, err
+	}
+	sources.RecordCheckBundles(conf.CheckBundles)
+	return conf, sources, nil
+}
+
+// MergeConfigAndParse parses config that wasn't read from a file, e.g. from a ConfigMap. It can extend
+// other configs by URL or absolute path. If mergeConfig is set, the result is merged on top of the default config.
+// checkPaths are added to the config's checkPaths.
+func MergeConfigAndParse(rawBytes []byte, checkPaths []string, mergeConfig bool) (Configuration, error) {
+	loader := newConfigLayerLoader()
+	if err := loader.add("", rawBytes); err != nil {
 		return Configuration{}, err
 	}
+	mergedBytes, _, err := mergeConfigLayerFiles(loader.layers, mergeConfig)
+	if err != nil {
+		return Configuration{}, err
+	}
+	mergedBytes, err = appendCheckPaths(mergedBytes, checkPaths)
+	if err != nil {
+		return Configuration{}, err
+	}
+	return parseAndLoadCheckPaths(mergedBytes)
+}
 
-	return Parse(rawBytes)
+// appendCheckPaths adds check bundle locations to the end of a merged config's checkPaths
+func appendCheckPaths(rawBytes []byte, checkPaths []string) ([]byte, error) {
+	if len(checkPaths) == 0 {
+		return rawBytes, nil
+	}
+	values, err := unmarshalConfigValues(rawBytes)
+	if err != nil {
+		return nil, err
+	}
+	existing, _ := values["checkPaths"].([]any)
+	for _, checkPath := range checkPaths {
+		existing = append(existing, checkPath)
+	}
+	values["checkPaths"] = existing
+	return json.Marshal(values)
 }
 
-func mergeConfigFile(customConfigPath string, mergeConfig bool) ([]byte, error) {
-	if customConfigPath == "" {
-		return defaultConfig, nil
+func mergeConfigFiles(customConfigPaths []string, mergeConfig bool) ([]byte, ConfigSources, error) {
+	if len(customConfigPaths) == 0 {
+		sources, err := getDefaultConfigSources()
+		return defaultConfig, sources, err
 	}
 
-	var customConfigContent []byte
-	var err error
-	if strings.HasPrefix(customConfigPath, "https://") || strings.HasPrefix(customConfigPath, "http://") {
-		// path is a url
-		response, err := http.Get(customConfigPath)
-		if err != nil {
-			return nil, err
-		}
-		customConfigContent, err = io.ReadAll(response.Body)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		// path is local
-		customConfigContent, err = os.ReadFile(customConfigPath)
-		if err != nil {
-			return nil, err
-		}
+	layers, err := loadConfigLayers(customConfigPaths)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mergeConfigLayerFiles(layers, mergeConfig)
+}
+
+func mergeConfigLayerFiles(layers []configLayer, mergeConfig bool) ([]byte, ConfigSources, error) {
+	sources := ConfigSources{}
+	customConfig := mergeConfigLayers(layers, sources)
+	customConfigContent, err := json.Marshal(customConfig)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if mergeConfig {
 		mergedConfig, err := mergeYaml(defaultConfig, customConfigContent)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		return mergedConfig, nil
+		mergedSources, err := getDefaultConfigSources()
+		if err != nil {
+			return nil, nil, err
+		}
+		mergedSources.overlay(customConfig, sources)
+		return mergedConfig, mergedSources, nil
 	}
 
-	return customConfigContent, nil
+	return customConfigContent, sources, nil
 }
 
-// Parse parses config from a byte array.
+// Parse parses config from a byte array. It doesn't read the config's checkPaths, so a config that has
+// any isn't validated: load them with LoadCheckPaths, then call Validate.
 func Parse(rawBytes []byte) (Configuration, error) {
+	conf, err := parse(rawBytes)
+	if err != nil || len(conf.CheckPaths) > 0 {
+		return conf, err
+	}
+	return conf, conf.Validate()
+}
+
+// parseAndLoadCheckPaths parses config, loads the checks in its checkPaths, and validates it
+func parseAndLoadCheckPaths(rawBytes []byte) (Configuration, error) {
+	conf, err := parse(rawBytes)
+	if err != nil {
+		return conf, err
+	}
+	if _, err := conf.LoadCheckPaths(conf.CheckPaths); err != nil {
+		return conf, err
+	}
+	return conf, conf.Validate()
+}
+
+// parse decodes config and initializes its custom checks, without validating it
+func parse(rawBytes []byte) (Configuration, error) {
 	reader := bytes.NewReader(rawBytes)
 	conf := Configuration{}
 	d := yaml.NewYAMLOrJSONDecoder(reader, 4096)
@@ -111,6 +222,9 @@ func Parse(rawBytes []byte) (Configuration, error) {
 			return conf, fmt.Errorf("Decoding config failed: %v", err)
 		}
 	}
+	if err := decodeStrict[Configuration](rawBytes); err != nil {
+		return conf, fmt.Errorf("Decoding config failed: %v", err)
+	}
 	for key, check := range conf.CustomChecks {
 		err := check.Initialize(key)
 		if err != nil {
@@ -121,7 +235,30 @@ func Parse(rawBytes []byte) (Configuration, error) {
 			return conf, fmt.Errorf("no severity specified for custom check %s. Please add the following to your configuration:\n\nchecks:\n  %s: warning # or danger/ignore\n\nto enable your check", key, key)
 		}
 	}
-	return conf, conf.Validate()
+	return conf, nil
+}
+
+// decodeStrict decodes every document in a config or check again, rejecting fields that don't exist in
+// T, so that typos aren't silently ignored
+func decodeStrict[T any](rawBytes []byte) error {
+	d := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(rawBytes), 4096)
+	for {
+		var document json.RawMessage
+		if err := d.Decode(&document); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if len(document) == 0 || string(document) == "null" {
+			continue
+		}
+		strict := json.NewDecoder(bytes.NewReader(document))
+		strict.DisallowUnknownFields()
+		if err := strict.Decode(new(T)); err != nil {
+			return err
+		}
+	}
 }
 
 // Validate checks if a config is valid
@@ -129,5 +266,42 @@ func (conf Configuration) Validate() error {
 	if len(conf.Checks) == 0 {
 		return errors.New("No checks were enabled")
 	}
-	return nil
+	var errs []error
+	for checkID, severity := range conf.Checks {
+		if !severity.isValid() {
+			errs = append(errs, fmt.Errorf("invalid severity %q for check %s", severity, checkID))
+		}
+		if !conf.isKnownCheck(checkID) {
+			errs = append(errs, fmt.Errorf("check %s does not match any built-in or custom check", checkID))
+		}
+	}
+	for idx, exemption := range conf.Exemptions {
+		if err := exemption.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("exemption %d: %w", idx, err))
+		}
+	}
+	for idx, override := range conf.SeverityOverrides {
+		if err := override.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("severity override %d: %w", idx, err))
+		}
+		for checkID := range override.Checks {
+			if _, ok := conf.Checks[checkID]; !ok {
+				errs = append(errs, fmt.Errorf("severity override %d: check %s has no severity in checks", idx, checkID))
+			}
+		}
+	}
+	for _, checkID := range conf.Mutations {
+		if !conf.isKnownCheck(checkID) {
+			errs = append(errs, fmt.Errorf("mutation %s does not match any built-in or custom check", checkID))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (conf Configuration) isKnownCheck(checkID string) bool {
+	if _, ok := conf.CustomChecks[checkID]; ok {
+		return true
+	}
+	_, ok := BuiltInChecks[checkID]
+	return ok
 }
@@ -0,0 +1,82 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/qri-io/jsonschema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ConfigSchema is the JSON Schema for Polaris config files
+//
+//go:embed config.schema.json
+var ConfigSchema []byte
+
+// ValidateSchema validates every document in a YAML or JSON config file against ConfigSchema.
+// It only checks the structure of the file; Parse also checks that the checks it refers to exist.
+func ValidateSchema(ctx context.Context, rawBytes []byte) error {
+	schema := &jsonschema.Schema{}
+	if err := json.Unmarshal(ConfigSchema, schema); err != nil {
+		return err
+	}
+	var errs []error
+	d := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(rawBytes), 4096)
+	for {
+		var document json.RawMessage
+		if err := d.Decode(&document); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("Decoding config failed: %v", err)
+		}
+		if len(document) == 0 || string(document) == "null" {
+			continue
+		}
+		keyErrors, err := schema.ValidateBytes(ctx, document)
+		if err != nil {
+			return err
+		}
+		for _, keyError := range keyErrors {
+			errs = append(errs, fmt.Errorf("%s: %s", keyError.PropertyPath, keyError.Message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateFile validates a config file or URL against ConfigSchema, then parses it along with the configs
+// it extends, so that unknown fields, invalid severities and unknown checks are all reported
+func ValidateFile(ctx context.Context, location string) error {
+	rawBytes, err := readConfigLocation(location)
+	if err != nil {
+		return err
+	}
+	// Strict decoding names unknown fields, which the schema only reports as additional properties
+	if err := decodeStrict[Configuration](rawBytes); err != nil {
+		return fmt.Errorf("Decoding config failed: %v", err)
+	}
+	if err := ValidateSchema(ctx, rawBytes); err != nil {
+		return err
+	}
+	_, err = MergeConfigAndParseFiles([]string{location}, false)
+	return err
+}
@@ -20,6 +20,8 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 	"time"
@@ -67,7 +69,7 @@ customChecks:
     failureMessage: Security context should be set
     category: Security
     target: Container
-    jsonSchema: >
+    schemaString: >
       {
         "$schema": "https://json-schema.org/draft/2019-09/schema",
         "type": "object",
@@ -184,3 +186,46 @@ func testParsedConfig(t *testing.T, config *Configuration) {
 	assert.Equal(t, SeverityWarning, config.Checks["cpuRequestsMissing"])
 	assert.Equal(t, Severity(""), config.Checks["cpuLimitsMissing"])
 }
+
+func TestParseStrict(t *testing.T) {
+	_, err := Parse([]byte("checks:\n  hostIPCSet: danger\nchecsk:\n  hostPIDSet: danger\n"))
+	assert.EqualError(t, err, `Decoding config failed: json: unknown field "checsk"`)
+
+	_, err = Parse([]byte("checks:\n  hostIPCSet: danger\nexemptions:\n- rules: [hostIPCSet]\n  controllerName: [foo]\n"))
+	assert.EqualError(t, err, `Decoding config failed: json: unknown field "controllerName"`)
+}
+
+func TestValidateChecks(t *testing.T) {
+	_, err := Parse([]byte("checks:\n  runAsRootAlowed: danger\n  hostIPCSet: critical\nmutations:\n- hostPIDSet\n- nope\n"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "check runAsRootAlowed does not match any built-in or custom check")
+		assert.Contains(t, err.Error(), `invalid severity "critical" for check hostIPCSet`)
+		assert.Contains(t, err.Error(), "mutation nope does not match any built-in or custom check")
+		assert.NotContains(t, err.Error(), "mutation hostPIDSet")
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	for _, file := range []string{"default.yaml", "examples/config-full.yaml"} {
+		content, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		assert.NoError(t, ValidateSchema(context.Background(), content), file)
+	}
+
+	err := ValidateSchema(context.Background(), []byte("checks:\n  hostIPCSet: critical\nexemptions:\n- rules: [hostIPCSet]\n  namespaceSelector:\n    matchExpressions:\n    - key: team\n      operator: Is\n"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `/checks/hostIPCSet: should be one of ["ignore", "warning", "danger"]`)
+		assert.Contains(t, err.Error(), `/exemptions/0/namespaceSelector/matchExpressions/0/operator: should be one of ["In", "NotIn", "Exists", "DoesNotExist"]`)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	assert.NoError(t, os.WriteFile(valid, []byte("checks:\n  hostIPCSet: danger\n"), 0644))
+	assert.NoError(t, ValidateFile(context.Background(), valid))
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	assert.NoError(t, os.WriteFile(invalid, []byte("checks:\n  runAsRootAlowed: danger\n"), 0644))
+	assert.EqualError(t, ValidateFile(context.Background(), invalid), "check runAsRootAlowed does not match any built-in or custom check")
+}
@@ -15,45 +15,158 @@
 package config
 
 import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
+// ExemptionSubject is what a check runs against, for matching exemptions and severity overrides
+type ExemptionSubject struct {
+	Kind   string
+	Object metav1.Object
+	// Namespace is the object's namespace, if known. It's needed to match namespace selectors
+	Namespace     metav1.Object
+	ContainerName string
+}
+
 // IsActionable determines whether a check is actionable given the current configuration
 func (conf Configuration) IsActionable(ruleID string, objMeta metav1.Object, containerName string) bool {
-	if severity, ok := conf.Checks[ruleID]; !ok || !severity.IsActionable() {
+	return conf.IsActionableFor(ruleID, ExemptionSubject{Object: objMeta, ContainerName: containerName})
+}
+
+// IsActionableFor determines whether a check is actionable for a subject, given the current configuration
+func (conf Configuration) IsActionableFor(ruleID string, subject ExemptionSubject) bool {
+	if severity := conf.GetSeverity(ruleID, subject); !severity.IsActionable() {
 		return false
 	}
+	_, exemption := conf.GetExemption(ruleID, subject)
+	return exemption == nil
+}
+
+// GetExemption returns the first config exemption that applies to a check, along with its index in
+// conf.Exemptions. It returns -1 and nil if no exemption applies. Expired exemptions are ignored.
+func (conf Configuration) GetExemption(ruleID string, subject ExemptionSubject) (int, *Exemption) {
 	if conf.DisallowExemptions || conf.DisallowConfigExemptions {
-		return true
+		return -1, nil
 	}
-	for _, exemption := range conf.Exemptions {
-		if exemption.Namespace != "" && exemption.Namespace != objMeta.GetNamespace() {
+	now := time.Now()
+	for idx, exemption := range conf.Exemptions {
+		if exemption.IsExpired(now) {
 			continue
 		}
+		if exemption.Matches(ruleID, subject) {
+			return idx, &conf.Exemptions[idx]
+		}
+	}
+	return -1, nil
+}
 
-		checkIfRuleMatches := false
-		for _, rule := range exemption.Rules {
-			if rule != ruleID {
-				continue
-			}
-			checkIfRuleMatches = true
-			break
+// UsesNamespaceSelectors returns true if any exemption or severity override needs namespace labels to be matched
+func (conf Configuration) UsesNamespaceSelectors() bool {
+	for _, exemption := range conf.Exemptions {
+		if exemption.NamespaceSelector != nil {
+			return true
 		}
+	}
+	for _, override := range conf.SeverityOverrides {
+		if override.NamespaceSelector != nil {
+			return true
+		}
+	}
+	return false
+}
 
-		if len(exemption.Rules) == 0 || checkIfRuleMatches {
-			if !isExemptionCheckMatched(exemption.ControllerNames, objMeta.GetName()) {
-				continue
-			}
-			if isExemptionCheckMatched(exemption.ContainerNames, containerName) {
-				return false
-			}
+// Matches returns true if the exemption applies to a check on a subject, regardless of its expiry.
+// Every field that is set must match.
+func (exemption Exemption) Matches(ruleID string, subject ExemptionSubject) bool {
+	if len(exemption.Rules) > 0 && !slices.Contains(exemption.Rules, ruleID) {
+		return false
+	}
+	if len(exemption.Kinds) > 0 && !slices.Contains(exemption.Kinds, subject.Kind) {
+		return false
+	}
+	namespace := subject.Object.GetNamespace()
+	if exemption.Namespace != "" && exemption.Namespace != namespace {
+		return false
+	}
+	if len(exemption.Namespaces) > 0 && !isExemptionGlobMatched(exemption.Namespaces, namespace) {
+		return false
+	}
+	if !isExemptionCheckMatched(exemption.ControllerNames, subject.Object.GetName()) ||
+		!isExemptionPatternMatched(exemption.ControllerNamePatterns, subject.Object.GetName()) {
+		return false
+	}
+	if !isExemptionCheckMatched(exemption.ContainerNames, subject.ContainerName) ||
+		!isExemptionPatternMatched(exemption.ContainerNamePatterns, subject.ContainerName) {
+		return false
+	}
+	if !isExemptionSelectorMatched(exemption.Selector, subject.Object.GetLabels()) ||
+		!isExemptionSelectorMatched(exemption.AnnotationSelector, subject.Object.GetAnnotations()) {
+		return false
+	}
+	if exemption.NamespaceSelector != nil {
+		if subject.Namespace == nil || !isExemptionSelectorMatched(exemption.NamespaceSelector, subject.Namespace.GetLabels()) {
+			return false
 		}
 	}
 	return true
 }
 
+// validate checks that the exemption's expiry, patterns and selectors can be parsed
+func (exemption Exemption) validate() error {
+	if _, err := exemption.GetExpiry(); err != nil {
+		return err
+	}
+	for _, glob := range exemption.Namespaces {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q: %w", glob, err)
+		}
+	}
+	for _, pattern := range append(append([]string{}, exemption.ControllerNamePatterns...), exemption.ContainerNamePatterns...) {
+		if _, err := getExemptionRegexp(pattern); err != nil {
+			return fmt.Errorf("invalid name pattern %q: %w", pattern, err)
+		}
+	}
+	for _, selector := range []*metav1.LabelSelector{exemption.NamespaceSelector, exemption.Selector, exemption.AnnotationSelector} {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetExpiry parses the exemption's expiry. A date without a time expires at the end of that day, in UTC.
+// It returns the zero time if the exemption never expires.
+func (exemption Exemption) GetExpiry() (time.Time, error) {
+	if exemption.Expires == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.Parse(time.DateOnly, exemption.Expires); err == nil {
+		return date.AddDate(0, 0, 1), nil
+	}
+	expiry, err := time.Parse(time.RFC3339, exemption.Expires)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q, expected a date like 2006-01-02 or an RFC 3339 time", exemption.Expires)
+	}
+	return expiry, nil
+}
+
+// IsExpired returns true if the exemption has an expiry before now
+func (exemption Exemption) IsExpired(now time.Time) bool {
+	expiry, err := exemption.GetExpiry()
+	if err != nil || expiry.IsZero() {
+		return false
+	}
+	return !now.Before(expiry)
+}
+
 func isExemptionCheckMatched(arr []string, predicate string) bool {
 	if len(arr) == 0 {
 		return true
@@ -66,3 +179,51 @@ func isExemptionCheckMatched(arr []string, predicate string) bool {
 	}
 	return false
 }
+
+func isExemptionGlobMatched(globs []string, predicate string) bool {
+	for _, glob := range globs {
+		if matched, err := path.Match(glob, predicate); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func isExemptionPatternMatched(patterns []string, predicate string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if re, err := getExemptionRegexp(pattern); err == nil && re.MatchString(predicate) {
+			return true
+		}
+	}
+	return false
+}
+
+func isExemptionSelectorMatched(selector *metav1.LabelSelector, values map[string]string) bool {
+	if selector == nil {
+		return true
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(labels.Set(values))
+}
+
+// exemptionRegexps caches compiled name patterns, since exemptions are matched for every check
+var exemptionRegexps sync.Map
+
+// getExemptionRegexp compiles a name pattern, which must match the whole name
+func getExemptionRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := exemptionRegexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	exemptionRegexps.Store(pattern, re)
+	return re, nil
+}
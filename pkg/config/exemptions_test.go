@@ -16,6 +16,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -263,3 +264,150 @@ func TestContainerExemption(t *testing.T) {
 	actionable = parsedConf.IsActionable("pullPolicyNotAlways", createMeta("kube-system", "controller8"), "container71")
 	assert.True(t, actionable)
 }
+
+func TestExemptionExpiry(t *testing.T) {
+	now := time.Now()
+	parsedConf, err := Parse([]byte(`
+checks:
+  hostNetworkSet: danger
+exemptions:
+  - controllerNames:
+      - expired
+    expires: ` + now.AddDate(0, 0, -2).Format(time.DateOnly) + `
+    owner: platform-team
+    reason: migrating to the CNI plugin
+  - controllerNames:
+      - today
+    expires: ` + now.UTC().Format(time.DateOnly) + `
+  - controllerNames:
+      - expired
+      - active
+    expires: ` + now.Add(time.Hour).Format(time.RFC3339) + `
+    ticket: OPS-123
+`))
+	assert.NoError(t, err)
+
+	assert.False(t, parsedConf.IsActionable("hostNetworkSet", createMeta("", "today"), ""))
+
+	idx, exemption := parsedConf.GetExemption("hostNetworkSet", ExemptionSubject{Object: createMeta("", "expired")})
+	assert.Equal(t, 2, idx)
+	if assert.NotNil(t, exemption) {
+		assert.Equal(t, "OPS-123", exemption.Ticket)
+	}
+
+	parsedConf.Exemptions[2].Expires = now.Add(-time.Minute).Format(time.RFC3339)
+	assert.True(t, parsedConf.IsActionable("hostNetworkSet", createMeta("", "active"), ""))
+	assert.True(t, parsedConf.IsActionable("hostNetworkSet", createMeta("", "expired"), ""))
+}
+
+func TestExemptionExpiryParsing(t *testing.T) {
+	expiry, err := Exemption{Expires: "2024-02-29"}.GetExpiry()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), expiry)
+
+	expiry, err = Exemption{}.GetExpiry()
+	assert.NoError(t, err)
+	assert.True(t, expiry.IsZero())
+
+	_, err = Parse([]byte(`
+checks:
+  hostNetworkSet: danger
+exemptions:
+  - expires: next week
+`))
+	assert.EqualError(t, err, `exemption 0: invalid expiry "next week", expected a date like 2006-01-02 or an RFC 3339 time`)
+}
+
+func TestExemptionSelectors(t *testing.T) {
+	parsedConf, err := Parse([]byte(`
+checks:
+  hostNetworkSet: danger
+exemptions:
+  - namespaces:
+      - team-*
+    kinds:
+      - Deployment
+    rules:
+      - hostNetworkSet
+  - selector:
+      matchLabels:
+        team: platform
+  - annotationSelector:
+      matchExpressions:
+        - key: example.com/legacy
+          operator: Exists
+  - namespaceSelector:
+      matchLabels:
+        tier: system
+  - controllerNamePatterns:
+      - "node-exporter-[0-9]+"
+    containerNamePatterns:
+      - "sidecar|proxy"
+`))
+	assert.NoError(t, err)
+
+	subject := func(kind, namespace, name string) ExemptionSubject {
+		return ExemptionSubject{Kind: kind, Object: createMeta(namespace, name)}
+	}
+	assert.False(t, parsedConf.IsActionableFor("hostNetworkSet", subject("Deployment", "team-a", "web")))
+	assert.True(t, parsedConf.IsActionableFor("hostNetworkSet", subject("StatefulSet", "team-a", "web")))
+	assert.True(t, parsedConf.IsActionableFor("hostNetworkSet", subject("Deployment", "teams", "web")))
+
+	labelled := subject("Deployment", "default", "web")
+	labelled.Object.SetLabels(map[string]string{"team": "platform"})
+	assert.False(t, parsedConf.IsActionableFor("hostNetworkSet", labelled))
+	labelled.Object.SetLabels(map[string]string{"team": "payments"})
+	assert.True(t, parsedConf.IsActionableFor("hostNetworkSet", labelled))
+
+	annotated := subject("Deployment", "default", "web")
+	annotated.Object.SetAnnotations(map[string]string{"example.com/legacy": "yes"})
+	assert.False(t, parsedConf.IsActionableFor("hostNetworkSet", annotated))
+
+	inSystem := subject("Deployment", "kube-system", "web")
+	assert.True(t, parsedConf.IsActionableFor("hostNetworkSet", inSystem))
+	inSystem.Namespace = createMeta("", "kube-system")
+	inSystem.Namespace.SetLabels(map[string]string{"tier": "system"})
+	assert.False(t, parsedConf.IsActionableFor("hostNetworkSet", inSystem))
+	assert.True(t, parsedConf.UsesNamespaceSelectors())
+
+	exporter := subject("DaemonSet", "monitoring", "node-exporter-12")
+	assert.True(t, parsedConf.IsActionableFor("hostNetworkSet", exporter))
+	exporter.ContainerName = "proxy"
+	assert.False(t, parsedConf.IsActionableFor("hostNetworkSet", exporter))
+	exporter.ContainerName = "proxy-init"
+	assert.True(t, parsedConf.IsActionableFor("hostNetworkSet", exporter))
+	exporter = subject("DaemonSet", "monitoring", "node-exporter-x")
+	exporter.ContainerName = "proxy"
+	assert.True(t, parsedConf.IsActionableFor("hostNetworkSet", exporter))
+}
+
+func TestInvalidExemptionSelectors(t *testing.T) {
+	_, err := Parse([]byte(`
+checks:
+  hostNetworkSet: danger
+exemptions:
+  - controllerNamePatterns:
+      - "node-exporter-("
+`))
+	assert.ErrorContains(t, err, `exemption 0: invalid name pattern "node-exporter-("`)
+
+	_, err = Parse([]byte(`
+checks:
+  hostNetworkSet: danger
+exemptions:
+  - namespaces:
+      - "team-["
+`))
+	assert.ErrorContains(t, err, `exemption 0: invalid namespace pattern "team-["`)
+
+	_, err = Parse([]byte(`
+checks:
+  hostNetworkSet: danger
+exemptions:
+  - selector:
+      matchExpressions:
+        - key: team
+          operator: Sometimes
+`))
+	assert.ErrorContains(t, err, "exemption 0: ")
+}
@@ -0,0 +1,220 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// appendedConfigKeys are lists that layers add to, rather than replace
+var appendedConfigKeys = []string{"exemptions", "severityOverrides", "mutations", "checkPaths"}
+
+// mergedConfigKeys are maps that layers merge into by key. Each entry is replaced whole, so a custom
+// check in a later layer replaces the custom check with the same ID.
+var mergedConfigKeys = []string{"checks", "customChecks"}
+
+// configLayer is a config file, or one of the configs it extends
+type configLayer struct {
+	location string
+	values   map[string]any
+}
+
+// configLayerLoader loads config files along with the configs they extend
+type configLayerLoader struct {
+	loading map[string]bool
+	loaded  map[string]bool
+	layers  []configLayer
+}
+
+func newConfigLayerLoader() *configLayerLoader {
+	return &configLayerLoader{loading: map[string]bool{}, loaded: map[string]bool{}}
+}
+
+// loadConfigLayers returns the config at each location, preceded by the configs it extends, in the
+// order they should be merged. A config that is extended more than once is only loaded the first time.
+func loadConfigLayers(locations []string) ([]configLayer, error) {
+	loader := newConfigLayerLoader()
+	for _, location := range locations {
+		if err := loader.load(location); err != nil {
+			return nil, err
+		}
+	}
+	return loader.layers, nil
+}
+
+func (l *configLayerLoader) load(location string) error {
+	if l.loading[location] {
+		return fmt.Errorf("config %s extends itself", location)
+	}
+	if l.loaded[location] {
+		return nil
+	}
+	l.loading[location] = true
+	defer delete(l.loading, location)
+
+	content, err := readConfigLocation(location)
+	if err != nil {
+		return err
+	}
+	return l.add(location, content)
+}
+
+// add adds a config that has been read, after the configs it extends
+func (l *configLayerLoader) add(location string, content []byte) error {
+	layer, err := unmarshalConfigValues(content)
+	if err != nil {
+		return fmt.Errorf("Decoding config %s failed: %v", location, err)
+	}
+	extends, err := getExtends(layer)
+	if err != nil {
+		return fmt.Errorf("config %s: %w", location, err)
+	}
+	delete(layer, "extends")
+	resolveWASMLocations(location, layer)
+	resolveCheckPaths(location, layer)
+	for _, extended := range extends {
+		if err := l.load(resolveConfigLocation(location, extended)); err != nil {
+			return err
+		}
+	}
+	l.loaded[location] = true
+	l.layers = append(l.layers, configLayer{location: location, values: layer})
+	return nil
+}
+
+// unmarshalConfigValues decodes a YAML or JSON config into plain values. Converting to JSON first keeps
+// values like dates as strings, just as they're read into a Configuration.
+func unmarshalConfigValues(content []byte) (map[string]any, error) {
+	values := map[string]any{}
+	jsonContent, err := yaml.ToJSON(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonContent, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func getExtends(layer map[string]any) ([]string, error) {
+	switch extends := layer["extends"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{extends}, nil
+	case []any:
+		locations := make([]string, len(extends))
+		for idx, location := range extends {
+			str, ok := location.(string)
+			if !ok {
+				return nil, fmt.Errorf("extends must be a list of paths or URLs")
+			}
+			locations[idx] = str
+		}
+		return locations, nil
+	}
+	return nil, fmt.Errorf("extends must be a list of paths or URLs")
+}
+
+// mergeConfigLayers merges config layers in order, so later layers take precedence. Checks and custom
+// checks are merged by ID, exemptions, severity overrides and mutations are appended, and every other
+// field is replaced. The layer each value came from is recorded in sources.
+func mergeConfigLayers(layers []configLayer, sources ConfigSources) map[string]any {
+	merged := map[string]any{}
+	for _, layer := range layers {
+		source := getConfigLocationSource(layer.location)
+		for key, value := range layer.values {
+			if slices.Contains(mergedConfigKeys, key) {
+				existing, existingOK := merged[key].(map[string]any)
+				values, ok := value.(map[string]any)
+				if existingOK && ok {
+					for id, entry := range values {
+						existing[id] = entry
+					}
+					sources.record(key, values, source)
+					continue
+				}
+			} else if slices.Contains(appendedConfigKeys, key) {
+				existing, existingOK := merged[key].([]any)
+				values, ok := value.([]any)
+				if existingOK && ok {
+					for _, entry := range values {
+						// Mutations are check IDs, which only need to be listed once
+						if key == "mutations" && slices.Contains(existing, entry) {
+							continue
+						}
+						sources[fmt.Sprintf("%s[%d]", key, len(existing))] = source
+						existing = append(existing, entry)
+					}
+					merged[key] = existing
+					continue
+				}
+			}
+			sources.clear(key)
+			sources.record(key, value, source)
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+func getConfigLocationSource(location string) string {
+	if isConfigURL(location) {
+		return "url " + location
+	}
+	return "file " + location
+}
+
+func isConfigURL(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// resolveConfigLocation resolves a location that a config extends, relative to the config's own location
+func resolveConfigLocation(base, location string) string {
+	if isConfigURL(location) {
+		return location
+	}
+	if isConfigURL(base) {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return location
+		}
+		ref, err := url.Parse(location)
+		if err != nil {
+			return location
+		}
+		return baseURL.ResolveReference(ref).String()
+	}
+	if filepath.IsAbs(location) {
+		return location
+	}
+	return filepath.Join(filepath.Dir(base), location)
+}
+
+// readConfigLocation reads a config from a local path or URL
+func readConfigLocation(location string) ([]byte, error) {
+	if !isConfigURL(location) {
+		return os.ReadFile(location)
+	}
+	return remoteFetcher.fetch(location)
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, path, content string) string {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLayeredConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, "org", "baseline.yaml"), `
+displayName: baseline
+checks:
+  hostIPCSet: danger
+  runAsRootAllowed: warning
+customChecks:
+  teamLabel:
+    successMessage: Team label is set
+    failureMessage: Team label should be set
+    category: Reliability
+    target: Controller
+    schema:
+      required: ["metadata"]
+exemptions:
+  - namespace: kube-system
+mutations:
+  - pullPolicyNotAlways
+`)
+	writeConfigFile(t, filepath.Join(dir, "org", "security.yaml"), `
+extends:
+  - baseline.yaml
+checks:
+  runAsRootAllowed: danger
+  teamLabel: warning
+`)
+	app := writeConfigFile(t, filepath.Join(dir, "app", "polaris.yaml"), `
+extends:
+  - ../org/security.yaml
+  - ../org/baseline.yaml
+displayName: app
+checks:
+  hostIPCSet: ignore
+customChecks:
+  teamLabel:
+    successMessage: Team label is set
+    failureMessage: Team label is required
+    category: Reliability
+    target: Controller
+    schema:
+      required: ["metadata"]
+exemptions:
+  - namespace: app-system
+    reason: legacy
+mutations:
+  - pullPolicyNotAlways
+  - hostIPCSet
+`)
+	local := writeConfigFile(t, filepath.Join(dir, "local.yaml"), `
+exemptions:
+  - namespace: scratch
+`)
+
+	parsedConf, err := MergeConfigAndParseFiles([]string{app, local}, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "app", parsedConf.DisplayName)
+	assert.Equal(t, map[string]Severity{
+		"hostIPCSet":       SeverityIgnore,
+		"runAsRootAllowed": SeverityDanger,
+		"teamLabel":        SeverityWarning,
+	}, parsedConf.Checks)
+	assert.Equal(t, "Team label is required", parsedConf.CustomChecks["teamLabel"].FailureMessage)
+	assert.Equal(t, []Exemption{
+		{Namespace: "kube-system"},
+		{Namespace: "app-system", Reason: "legacy"},
+		{Namespace: "scratch"},
+	}, parsedConf.Exemptions)
+	assert.Equal(t, []string{"pullPolicyNotAlways", "hostIPCSet"}, parsedConf.Mutations)
+	assert.Empty(t, parsedConf.Extends)
+}
+
+func TestLayeredConfigCycle(t *testing.T) {
+	dir := t.TempDir()
+	a := writeConfigFile(t, filepath.Join(dir, "a.yaml"), "extends: [b.yaml]\nchecks:\n  hostIPCSet: danger\n")
+	writeConfigFile(t, filepath.Join(dir, "b.yaml"), "extends: [a.yaml]\n")
+
+	_, err := MergeConfigAndParseFiles([]string{a}, false)
+	assert.EqualError(t, err, "config "+a+" extends itself")
+}
+
+func TestLayeredConfigKeepsDates(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, filepath.Join(dir, "polaris.yaml"), `
+exemptions:
+  - namespace: kube-system
+    expires: 2025-06-30
+`)
+	parsedConf, err := MergeConfigAndParseFiles([]string{path}, true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2025-06-30", parsedConf.Exemptions[0].Expires)
+	}
+}
+
+func TestResolveConfigLocation(t *testing.T) {
+	assert.Equal(t, "/etc/polaris/base.yaml", resolveConfigLocation("/etc/polaris/config.yaml", "base.yaml"))
+	assert.Equal(t, "/base.yaml", resolveConfigLocation("/etc/polaris/config.yaml", "/base.yaml"))
+	assert.Equal(t, "https://example.com/org/base.yaml", resolveConfigLocation("https://example.com/org/polaris.yaml", "base.yaml"))
+	assert.Equal(t, "https://example.com/base.yaml", resolveConfigLocation("/etc/polaris/config.yaml", "https://example.com/base.yaml"))
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// regoPolicy is a compiled Rego module
+type regoPolicy interface {
+	// eval returns the messages of the module's deny and violation rules
+	eval(ctx context.Context, input map[string]any) ([]string, error)
+}
+
+// initializeRego compiles the check's Rego module, if it has one
+func (check *SchemaCheck) initializeRego() error {
+	check.regoPolicy = nil
+	if check.Rego == "" {
+		return nil
+	}
+	var err error
+	check.regoPolicy, err = newOPAPolicy(check.Rego)
+	if err != nil {
+		return fmt.Errorf("check %s: compiling rego: %w", check.ID, err)
+	}
+	return nil
+}
+
+// HasRego returns true if the check has a Rego module to evaluate
+func (check SchemaCheck) HasRego() bool {
+	return check.Rego != ""
+}
+
+// CheckRego evaluates the check's Rego module, which fails if its deny or violation rules produce any
+// messages. The messages are returned joined together.
+func (check SchemaCheck) CheckRego(ctx context.Context, input CheckInput) (bool, string, error) {
+	if check.regoPolicy == nil {
+		// The check wasn't initialized, e.g. because it was built in code
+		if err := check.initializeRego(); err != nil {
+			return false, "", err
+		}
+		if check.regoPolicy == nil {
+			return true, "", nil
+		}
+	}
+	messages, err := check.regoPolicy.eval(ctx, input.variables())
+	if err != nil {
+		return false, "", fmt.Errorf("check %s: evaluating rego: %w", check.ID, err)
+	}
+	return len(messages) == 0, strings.Join(messages, "; "), nil
+}
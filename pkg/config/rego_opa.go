@@ -0,0 +1,82 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// opaPolicy evaluates a Rego module in-process with the embedded OPA engine
+type opaPolicy struct {
+	query rego.PreparedEvalQuery
+}
+
+func newOPAPolicy(module string) (regoPolicy, error) {
+	parsed, err := ast.ParseModule("check.rego", module)
+	if err != nil {
+		return nil, err
+	}
+	pkg := parsed.Package.Path.String()
+	query, err := rego.New(
+		rego.Query(fmt.Sprintf("deny := [x | x := %s.deny[_]]; violation := [x | x := %s.violation[_]]", pkg, pkg)),
+		rego.ParsedModule(parsed),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &opaPolicy{query: query}, nil
+}
+
+func (p *opaPolicy) eval(ctx context.Context, input map[string]any) ([]string, error) {
+	results, err := p.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, err
+	}
+	var messages []string
+	for _, result := range results {
+		for _, rule := range []string{"deny", "violation"} {
+			values, _ := result.Bindings[rule].([]any)
+			for _, value := range values {
+				messages = append(messages, getRegoMessage(value))
+			}
+		}
+	}
+	sort.Strings(messages)
+	return messages, nil
+}
+
+// getRegoMessage returns the message of a deny or violation result, which is either a string or an
+// object with a msg field, as in Gatekeeper
+func getRegoMessage(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]any:
+		if msg, ok := v["msg"].(string); ok {
+			return msg
+		}
+	}
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(jsonBytes)
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var regoConf = `
+checks:
+  approvedRegistry: danger
+customChecks:
+  approvedRegistry:
+    successMessage: Image comes from an approved registry
+    failureMessage: Image should come from an approved registry
+    category: Security
+    target: Container
+    rego: |
+      package polaris.approved_registry
+
+      deny contains msg if {
+        not startswith(input.container.image, "quay.io/")
+        msg := sprintf("Image %s should come from quay.io", [input.container.image])
+      }
+
+      violation contains {"msg": msg} if {
+        endswith(input.container.image, ":latest")
+        msg := "Image should be pinned"
+      }
+`
+
+func TestCheckRego(t *testing.T) {
+	c, err := Parse([]byte(regoConf))
+	assert.NoError(t, err)
+	check := c.CustomChecks["approvedRegistry"]
+
+	passes, message, err := check.CheckRego(context.Background(), CheckInput{Container: map[string]any{"image": "quay.io/foo:1.0"}})
+	assert.NoError(t, err)
+	assert.True(t, passes)
+	assert.Equal(t, "", message)
+
+	passes, message, err = check.CheckRego(context.Background(), CheckInput{Container: map[string]any{"image": "docker.io/foo:latest"}})
+	assert.NoError(t, err)
+	assert.False(t, passes)
+	assert.Equal(t, "Image docker.io/foo:latest should come from quay.io; Image should be pinned", message)
+
+	_, err = Parse([]byte("checks:\n  foo: warning\ncustomChecks:\n  foo:\n    target: Container\n    rego: |\n      package polaris.foo\n      deny contains msg if {\n"))
+	assert.ErrorContains(t, err, "check foo: compiling rego")
+}
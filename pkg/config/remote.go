@@ -0,0 +1,307 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultRemoteConfigTimeout is used when RemoteConfigOptions doesn't set a timeout
+const defaultRemoteConfigTimeout = 30 * time.Second
+
+// maxRemoteConfigSize limits how much of a response is read, so a misbehaving server can't exhaust memory
+const maxRemoteConfigSize = 16 << 20
+
+// remoteConfigRetryDelay is how long to wait before the first retry. It doubles after each attempt.
+var remoteConfigRetryDelay = time.Second
+
+// RemoteConfigOptions configures how configs are fetched from URLs
+type RemoteConfigOptions struct {
+	// BearerToken is sent as an Authorization header. It takes precedence over Username and Password.
+	BearerToken string
+	// Username and Password are sent with basic auth
+	Username string
+	Password string
+	// CredentialURLs are the configs the credentials are for, e.g. the ones passed with --config. Credentials
+	// are only sent to the same scheme, host and port, so that a config extending a URL on another host
+	// doesn't leak them, and never over plain http.
+	CredentialURLs []string
+	// CAFile is a PEM bundle of certificate authorities to trust, in addition to the system ones
+	CAFile string
+	// Timeout limits each attempt to fetch a config. Defaults to 30s.
+	Timeout time.Duration
+	// Retries is how many times to retry after a network error or a 5xx or 429 response
+	Retries int
+	// CacheDir keeps a copy of each remote config, which is revalidated with its ETag, and used if the
+	// config can't be fetched. Caching is disabled if it's empty.
+	CacheDir string
+}
+
+// remoteConfigFetcher fetches configs from URLs
+type remoteConfigFetcher struct {
+	options RemoteConfigOptions
+	client  *http.Client
+	// credentialOrigins are the origins of options.CredentialURLs
+	credentialOrigins map[string]bool
+}
+
+var remoteFetcher = &remoteConfigFetcher{client: &http.Client{Timeout: defaultRemoteConfigTimeout}}
+
+// SetRemoteConfigOptions sets how configs are fetched from URLs. It applies to every config loaded afterwards.
+func SetRemoteConfigOptions(options RemoteConfigOptions) error {
+	fetcher, err := newRemoteConfigFetcher(options)
+	if err != nil {
+		return err
+	}
+	remoteFetcher = fetcher
+	return nil
+}
+
+func newRemoteConfigFetcher(options RemoteConfigOptions) (*remoteConfigFetcher, error) {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = defaultRemoteConfigTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	if options.CAFile != "" {
+		caBundle, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", options.CAFile)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		client.Transport = transport
+	}
+	credentialOrigins := map[string]bool{}
+	hasCredentials := options.BearerToken != "" || options.Username != "" || options.Password != ""
+	for _, location := range options.CredentialURLs {
+		if !isConfigURL(location) {
+			continue
+		}
+		parsed, err := url.Parse(location)
+		if err != nil {
+			return nil, err
+		}
+		if hasCredentials && parsed.Scheme != "https" {
+			return nil, fmt.Errorf("refusing to send credentials for config %s over %s, use https", location, parsed.Scheme)
+		}
+		credentialOrigins[getOrigin(parsed)] = true
+	}
+	return &remoteConfigFetcher{options: options, client: client, credentialOrigins: credentialOrigins}, nil
+}
+
+// getOrigin returns the scheme, host and port of a URL
+func getOrigin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// retryableError is a failure to fetch a config that may succeed later, so a cached copy can be used instead
+type retryableError struct {
+	err error
+}
+
+func (e retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e retryableError) Unwrap() error {
+	return e.err
+}
+
+// fetch reads a config from a URL. A URL can pin the config's checksum with a fragment, e.g.
+// https://example.com/polaris.yaml#sha256=<hex digest>
+func (f *remoteConfigFetcher) fetch(location string) ([]byte, error) {
+	configURL, expectedSum, err := parsePinnedConfigURL(location)
+	if err != nil {
+		return nil, err
+	}
+	cached, etag := f.readCache(configURL)
+
+	var content []byte
+	delay := remoteConfigRetryDelay
+	for attempt := 0; ; attempt++ {
+		content, err = f.get(configURL, etag)
+		if err == nil {
+			break
+		}
+		var retryable retryableError
+		if !errors.As(err, &retryable) {
+			return nil, err
+		}
+		if attempt >= f.options.Retries {
+			if cached == nil {
+				return nil, err
+			}
+			logrus.Warnf("Using cached copy of config %s: %v", configURL, err)
+			content = cached
+			break
+		}
+		logrus.Debugf("Retrying config %s in %s: %v", configURL, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+	if content == nil {
+		// The server confirmed the cached copy is still current
+		content = cached
+	}
+
+	if expectedSum != "" {
+		sum := sha256.Sum256(content)
+		if actualSum := hex.EncodeToString(sum[:]); actualSum != expectedSum {
+			return nil, fmt.Errorf("config %s has sha256 %s, expected %s", configURL, actualSum, expectedSum)
+		}
+	}
+	return content, nil
+}
+
+// get fetches a config, returning nil content if it matches the cached ETag
+func (f *remoteConfigFetcher) get(configURL, etag string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, configURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.credentialOrigins[getOrigin(request.URL)] {
+		if f.options.BearerToken != "" {
+			request.Header.Set("Authorization", "Bearer "+f.options.BearerToken)
+		} else if f.options.Username != "" || f.options.Password != "" {
+			request.SetBasicAuth(f.options.Username, f.options.Password)
+		}
+	}
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, retryableError{err}
+	}
+	defer response.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxRemoteConfigSize+1))
+	if err != nil {
+		return nil, retryableError{err}
+	}
+	if len(content) > maxRemoteConfigSize {
+		return nil, fmt.Errorf("fetching config %s: larger than %d bytes", configURL, maxRemoteConfigSize)
+	}
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && etag != "":
+		return nil, nil
+	case response.StatusCode >= 200 && response.StatusCode < 300:
+		f.writeCache(configURL, content, response.Header.Get("ETag"))
+		return content, nil
+	case response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests:
+		return nil, retryableError{fmt.Errorf("fetching config %s: unexpected status %s", configURL, response.Status)}
+	}
+	return nil, fmt.Errorf("fetching config %s: unexpected status %s", configURL, response.Status)
+}
+
+// parsePinnedConfigURL splits the sha256 pin from a config URL
+func parsePinnedConfigURL(location string) (string, string, error) {
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return "", "", err
+	}
+	if parsed.Fragment == "" {
+		return location, "", nil
+	}
+	expectedSum, ok := strings.CutPrefix(parsed.Fragment, "sha256=")
+	if !ok {
+		return "", "", fmt.Errorf("config %s: unsupported fragment, expected #sha256=<hex digest>", location)
+	}
+	if _, err := hex.DecodeString(expectedSum); err != nil || len(expectedSum) != sha256.Size*2 {
+		return "", "", fmt.Errorf("config %s: invalid sha256 %q", location, expectedSum)
+	}
+	parsed.Fragment = ""
+	return parsed.String(), strings.ToLower(expectedSum), nil
+}
+
+func (f *remoteConfigFetcher) cachePath(configURL string) string {
+	sum := sha256.Sum256([]byte(configURL))
+	return filepath.Join(f.options.CacheDir, hex.EncodeToString(sum[:]))
+}
+
+// readCache returns the cached copy of a config and its ETag, if there is one
+func (f *remoteConfigFetcher) readCache(configURL string) ([]byte, string) {
+	if f.options.CacheDir == "" {
+		return nil, ""
+	}
+	content, err := os.ReadFile(f.cachePath(configURL) + ".yaml")
+	if err != nil {
+		return nil, ""
+	}
+	etag, err := os.ReadFile(f.cachePath(configURL) + ".etag")
+	if err != nil {
+		return content, ""
+	}
+	return content, string(etag)
+}
+
+func (f *remoteConfigFetcher) writeCache(configURL string, content []byte, etag string) {
+	if f.options.CacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(f.options.CacheDir, 0700); err != nil {
+		logrus.Warnf("Couldn't create config cache %s: %v", f.options.CacheDir, err)
+		return
+	}
+	path := f.cachePath(configURL)
+	if err := writeFileAtomically(path+".yaml", content); err != nil {
+		logrus.Warnf("Couldn't cache config %s: %v", configURL, err)
+		return
+	}
+	if etag == "" {
+		os.Remove(path + ".etag")
+	} else if err := writeFileAtomically(path+".etag", []byte(etag)); err != nil {
+		logrus.Warnf("Couldn't cache the ETag of config %s: %v", configURL, err)
+	}
+}
+
+// writeFileAtomically writes a file through a temporary file, so readers never see part of it
+func writeFileAtomically(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
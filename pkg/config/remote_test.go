@@ -0,0 +1,237 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	remoteConfigRetryDelay = time.Millisecond
+}
+
+func TestRemoteConfigStatus(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	fetcher, err := newRemoteConfigFetcher(RemoteConfigOptions{Retries: 2})
+	assert.NoError(t, err)
+	_, err = fetcher.fetch(srv.URL + "/polaris.yaml")
+	assert.EqualError(t, err, "fetching config "+srv.URL+"/polaris.yaml: unexpected status 404 Not Found")
+}
+
+// newTLSFetcher returns a fetcher that trusts the test server's certificate
+func newTLSFetcher(t *testing.T, srv *httptest.Server, options RemoteConfigOptions) *remoteConfigFetcher {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caFile, caBundle, 0644))
+	options.CAFile = caFile
+	fetcher, err := newRemoteConfigFetcher(options)
+	assert.NoError(t, err)
+	return fetcher
+}
+
+func TestRemoteConfigAuth(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	fetcher := newTLSFetcher(t, srv, RemoteConfigOptions{BearerToken: "secret", CredentialURLs: []string{srv.URL + "/polaris.yaml"}})
+	content, err := fetcher.fetch(srv.URL + "/other.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer secret", string(content))
+
+	fetcher = newTLSFetcher(t, srv, RemoteConfigOptions{Username: "polaris", Password: "secret", CredentialURLs: []string{srv.URL}})
+	content, err = fetcher.fetch(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "Basic cG9sYXJpczpzZWNyZXQ=", string(content))
+
+	// Credentials are only sent to the hosts they're for
+	fetcher = newTLSFetcher(t, srv, RemoteConfigOptions{BearerToken: "secret", CredentialURLs: []string{"https://example.com/polaris.yaml"}})
+	content, err = fetcher.fetch(srv.URL)
+	assert.NoError(t, err)
+	assert.Empty(t, string(content))
+
+	// and never over http
+	_, err = newRemoteConfigFetcher(RemoteConfigOptions{BearerToken: "secret", CredentialURLs: []string{"http://example.com/polaris.yaml"}})
+	assert.EqualError(t, err, "refusing to send credentials for config http://example.com/polaris.yaml over http, use https")
+}
+
+func TestRemoteConfigAuthExtends(t *testing.T) {
+	var otherAuthorization []string
+	other := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherAuthorization = append(otherAuthorization, r.Header.Get("Authorization"))
+		io.WriteString(w, confValidYAML)
+	}))
+	defer other.Close()
+	var authorization string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		io.WriteString(w, "extends:\n- "+other.URL+"/base.yaml\n")
+	}))
+	defer srv.Close()
+
+	// Test servers share a certificate, so the fetcher trusts both
+	location := srv.URL + "/polaris.yaml"
+	previous := remoteFetcher
+	remoteFetcher = newTLSFetcher(t, srv, RemoteConfigOptions{BearerToken: "secret", CredentialURLs: []string{location}})
+	defer func() { remoteFetcher = previous }()
+
+	c, _, err := MergeConfigAndParseFilesWithSources([]string{location}, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, SeverityWarning, c.Checks["cpuRequestsMissing"])
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, []string{""}, otherAuthorization, "the extended config's host should not receive the credentials")
+}
+
+func TestRemoteConfigSizeLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, maxRemoteConfigSize+1))
+	}))
+	defer srv.Close()
+
+	fetcher, err := newRemoteConfigFetcher(RemoteConfigOptions{})
+	assert.NoError(t, err)
+	_, err = fetcher.fetch(srv.URL)
+	assert.EqualError(t, err, fmt.Sprintf("fetching config %s: larger than %d bytes", srv.URL, maxRemoteConfigSize))
+}
+
+func TestRemoteConfigRetries(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, confValidYAML)
+	}))
+	defer srv.Close()
+
+	fetcher, err := newRemoteConfigFetcher(RemoteConfigOptions{Retries: 1})
+	assert.NoError(t, err)
+	_, err = fetcher.fetch(srv.URL)
+	assert.EqualError(t, err, "fetching config "+srv.URL+": unexpected status 503 Service Unavailable")
+	assert.Equal(t, 2, requests)
+
+	requests = 0
+	fetcher, err = newRemoteConfigFetcher(RemoteConfigOptions{Retries: 2})
+	assert.NoError(t, err)
+	content, err := fetcher.fetch(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, confValidYAML, string(content))
+	assert.Equal(t, 3, requests)
+}
+
+func TestRemoteConfigChecksum(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, confValidYAML)
+	}))
+	defer srv.Close()
+	sum := sha256.Sum256([]byte(confValidYAML))
+	digest := hex.EncodeToString(sum[:])
+
+	fetcher, err := newRemoteConfigFetcher(RemoteConfigOptions{})
+	assert.NoError(t, err)
+	content, err := fetcher.fetch(srv.URL + "/polaris.yaml#sha256=" + digest)
+	assert.NoError(t, err)
+	assert.Equal(t, confValidYAML, string(content))
+
+	otherDigest := hex.EncodeToString(make([]byte, sha256.Size))
+	_, err = fetcher.fetch(srv.URL + "/polaris.yaml#sha256=" + otherDigest)
+	assert.EqualError(t, err, "config "+srv.URL+"/polaris.yaml has sha256 "+digest+", expected "+otherDigest)
+
+	_, err = fetcher.fetch(srv.URL + "/polaris.yaml#md5=abc")
+	assert.EqualError(t, err, "config "+srv.URL+"/polaris.yaml#md5=abc: unsupported fragment, expected #sha256=<hex digest>")
+
+	// Pins work for configs that are extended too
+	dir := t.TempDir()
+	app := writeConfigFile(t, filepath.Join(dir, "app.yaml"), "extends:\n  - "+srv.URL+"/polaris.yaml#sha256="+otherDigest+"\n")
+	_, err = MergeConfigAndParseFile(app, false)
+	assert.EqualError(t, err, "config "+srv.URL+"/polaris.yaml has sha256 "+digest+", expected "+otherDigest)
+}
+
+func TestRemoteConfigCache(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, confValidYAML)
+	}))
+
+	fetcher, err := newRemoteConfigFetcher(RemoteConfigOptions{CacheDir: t.TempDir()})
+	assert.NoError(t, err)
+	content, err := fetcher.fetch(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, confValidYAML, string(content))
+
+	// The cached copy is revalidated with its ETag
+	content, err = fetcher.fetch(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, confValidYAML, string(content))
+	assert.Equal(t, 2, requests)
+
+	// The cached copy is used if the server can't be reached
+	srv.Close()
+	content, err = fetcher.fetch(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, confValidYAML, string(content))
+
+	uncached, err := newRemoteConfigFetcher(RemoteConfigOptions{})
+	assert.NoError(t, err)
+	_, err = uncached.fetch(srv.URL)
+	assert.Error(t, err)
+}
+
+func TestRemoteConfigCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, confValidYAML)
+	}))
+	defer srv.Close()
+
+	fetcher, err := newRemoteConfigFetcher(RemoteConfigOptions{})
+	assert.NoError(t, err)
+	_, err = fetcher.fetch(srv.URL)
+	assert.ErrorContains(t, err, "certificate")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caFile, caBundle, 0644))
+	fetcher, err = newRemoteConfigFetcher(RemoteConfigOptions{CAFile: caFile})
+	assert.NoError(t, err)
+	content, err := fetcher.fetch(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, confValidYAML, string(content))
+
+	_, err = newRemoteConfigFetcher(RemoteConfigOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+}
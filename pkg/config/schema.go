@@ -64,7 +64,8 @@ type Mutation struct {
 	Comment string
 }
 
-// SchemaCheck is a Polaris check that runs using JSON Schema
+// SchemaCheck is a Polaris check that runs using JSON Schema, a CEL expression, a Rego policy and/or a
+// WebAssembly module
 type SchemaCheck struct {
 	ID                      string                       `yaml:"id" json:"id"`
 	Category                string                       `yaml:"category" json:"category"`
@@ -81,6 +82,28 @@ type SchemaCheck struct {
 	AdditionalSchemaStrings map[string]string            `yaml:"additionalSchemaStrings" json:"additionalSchemaStrings"`
 	AdditionalValidators    map[string]jsonschema.Schema `yaml:"-" json:"-"`
 	Mutations               []Mutation                   `yaml:"mutations" json:"mutations"`
+	CEL                     string                       `yaml:"cel" json:"cel"`
+	CELMessage              string                       `yaml:"celMessage" json:"celMessage"`
+	RelatedResources        []string                     `yaml:"relatedResources" json:"relatedResources"`
+	Rego                    string                       `yaml:"rego" json:"rego"`
+	WASM                    string                       `yaml:"wasm" json:"wasm"`
+
+	// templates holds the parsed templates of a templated check, keyed by kind ("" for the main schema)
+	templates     map[string]*template.Template
+	templateCache *schemaCache
+	// compiled validators are set once a check has been initialized or templated
+	compiled           *compiledSchema
+	additionalCompiled map[string]*compiledSchema
+	// celProgram and celMessageProgram are the compiled CEL expressions, if the check has any
+	celProgram        *celProgram
+	celMessageProgram *celProgram
+	// regoPolicy is the compiled Rego module, if the check has one
+	regoPolicy regoPolicy
+	// wasmModule is the compiled WebAssembly module, if the check has one
+	wasmModule wasmModule
+	// wasmContent is the WebAssembly module of a check from a bundle tarball, which is used instead of
+	// reading WASM
+	wasmContent []byte
 }
 
 type resourceMinimum string
@@ -101,14 +124,26 @@ func UnmarshalYAMLOrJSON(raw []byte, dest any) error {
 	return nil
 }
 
-// ParseCheck parses a check from a byte array
+// ParseCheck parses a check from a byte array, rejecting unknown fields like a config's custom checks
 func ParseCheck(id string, rawBytes []byte) (SchemaCheck, error) {
+	check, err := decodeCheck(rawBytes)
+	if err != nil {
+		return check, err
+	}
+	err = check.Initialize(id)
+	return check, err
+}
+
+// decodeCheck decodes a check without initializing it
+func decodeCheck(rawBytes []byte) (SchemaCheck, error) {
 	check := SchemaCheck{}
 	err := UnmarshalYAMLOrJSON(rawBytes, &check)
 	if err != nil {
 		return check, err
 	}
-	check.Initialize(id)
+	if err := decodeStrict[SchemaCheck](rawBytes); err != nil {
+		return check, fmt.Errorf("Decoding schema check failed: %v", err)
+	}
 	return check, nil
 }
 
@@ -213,6 +248,15 @@ func validateRange(limit any, data any, isMinimum bool) *[]jsonschema.KeyError {
 // Initialize sets up the schema
 func (check *SchemaCheck) Initialize(id string) error {
 	check.ID = id
+	if err := check.initializeCEL(); err != nil {
+		return err
+	}
+	if err := check.initializeRego(); err != nil {
+		return err
+	}
+	if err := check.initializeWASM(); err != nil {
+		return err
+	}
 	if check.SchemaString == "" {
 		jsonBytes, err := json.Marshal(check.Schema)
 		if err != nil {
@@ -232,60 +276,112 @@ func (check *SchemaCheck) Initialize(id string) error {
 	}
 	check.Schema = map[string]any{}
 	check.AdditionalSchemas = map[string]map[string]any{}
-	return nil
-}
-
-// TemplateForResource fills out a check's templated fields given a particular resource
-func (check SchemaCheck) TemplateForResource(res any) (*SchemaCheck, error) {
-	newCheck := check // Make a copy of the check, since we're going to modify the schema
 
-	templateStrings := map[string]string{
-		"": newCheck.SchemaString,
+	schemaStrings := map[string]string{"": check.SchemaString}
+	maps.Copy(schemaStrings, check.AdditionalSchemaStrings)
+	for _, schemaString := range schemaStrings {
+		if isTemplated(schemaString) {
+			return check.parseTemplates(schemaStrings)
+		}
 	}
-	maps.Copy(templateStrings, newCheck.AdditionalSchemaStrings)
-	newCheck.SchemaString = ""
-	newCheck.AdditionalSchemaStrings = map[string]string{}
+	// Schemas without any template actions render to themselves, so they only need to be compiled once
+	return check.setValidators(schemaStrings, nil)
+}
 
+func (check *SchemaCheck) parseTemplates(templateStrings map[string]string) error {
+	check.templates = map[string]*template.Template{}
 	for kind, tmplString := range templateStrings {
-		tmpl := template.New(newCheck.ID).Funcs(template.FuncMap{
+		tmpl := template.New(check.ID).Funcs(template.FuncMap{
 			"hasPrefix": strings.HasPrefix,
 			"hasSuffix": strings.HasSuffix,
 		})
 		tmpl, err := tmpl.Parse(tmplString)
 		if err != nil {
-			return nil, err
-		}
-		w := bytes.Buffer{}
-		err = tmpl.Execute(&w, res)
-		if err != nil {
-			return nil, err
+			return err
 		}
-		templated := w.String()
-		if strings.TrimSpace(templated) == "" {
+		check.templates[kind] = tmpl
+	}
+	check.templateCache = newSchemaCache()
+	return nil
+}
+
+// setValidators compiles the given schemas, keyed by kind ("" for the main schema). Blank schemas are skipped.
+func (check *SchemaCheck) setValidators(schemaStrings map[string]string, cache *schemaCache) error {
+	check.SchemaString = ""
+	check.Validator = jsonschema.Schema{}
+	check.compiled = nil
+	check.AdditionalSchemaStrings = map[string]string{}
+	check.AdditionalValidators = map[string]jsonschema.Schema{}
+	check.additionalCompiled = map[string]*compiledSchema{}
+	for kind, schemaString := range schemaStrings {
+		if strings.TrimSpace(schemaString) == "" {
 			continue
 		}
-
+		compiled, err := cache.get(schemaString)
+		if err != nil {
+			return err
+		}
 		if kind == "" {
-			newCheck.SchemaString = templated
+			check.SchemaString = schemaString
+			check.Validator = *compiled.schema
+			check.compiled = compiled
 		} else {
-			newCheck.AdditionalSchemaStrings[kind] = templated
+			check.AdditionalSchemaStrings[kind] = schemaString
+			check.AdditionalValidators[kind] = *compiled.schema
+			check.additionalCompiled[kind] = compiled
+		}
+	}
+	if check.compiled == nil {
+		compiled, err := cache.get("")
+		if err != nil {
+			return err
 		}
+		check.compiled = compiled
 	}
+	return nil
+}
 
-	newCheck.AdditionalValidators = map[string]jsonschema.Schema{}
-	for kind, schemaStr := range newCheck.AdditionalSchemaStrings {
-		val := jsonschema.Schema{}
-		err := UnmarshalYAMLOrJSON([]byte(schemaStr), &val)
+// TemplateForResource fills out a check's templated fields given a particular resource
+func (check SchemaCheck) TemplateForResource(res any) (*SchemaCheck, error) {
+	newCheck := check // Make a copy of the check, since we're going to modify the schema
+	if newCheck.templates == nil {
+		if newCheck.compiled != nil {
+			return &newCheck, nil
+		}
+		// The check wasn't initialized, e.g. because it was built in code
+		templateStrings := map[string]string{"": newCheck.SchemaString}
+		maps.Copy(templateStrings, newCheck.AdditionalSchemaStrings)
+		if err := newCheck.parseTemplates(templateStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	rendered := map[string]string{}
+	for kind, tmpl := range newCheck.templates {
+		w := bytes.Buffer{}
+		err := tmpl.Execute(&w, res)
 		if err != nil {
 			return nil, err
 		}
-		newCheck.AdditionalValidators[kind] = val
+		rendered[kind] = w.String()
 	}
-	err := UnmarshalYAMLOrJSON([]byte(newCheck.SchemaString), &newCheck.Validator)
+	err := newCheck.setValidators(rendered, newCheck.templateCache)
 	if err != nil {
 		return nil, err
 	}
-	return &newCheck, err
+	return &newCheck, nil
+}
+
+// HasEmptyValidator returns true if the check's main schema doesn't validate anything
+func (check SchemaCheck) HasEmptyValidator() (bool, error) {
+	if check.compiled != nil {
+		return check.compiled.empty, nil
+	}
+	validatorBytes, err := json.Marshal(check.Validator)
+	if err != nil {
+		return false, err
+	}
+	return isEmptySchema(string(validatorBytes)), nil
 }
 
 // CheckPodSpec checks a pod spec against the schema
@@ -300,7 +396,7 @@ func (check SchemaCheck) CheckPodTemplate(ctx context.Context, podTemplate any)
 
 // CheckController checks a controler's spec against the schema
 func (check SchemaCheck) CheckController(ctx context.Context, bytes []byte) (bool, []jsonschema.KeyError, error) {
-	errs, err := check.Validator.ValidateBytes(ctx, bytes)
+	errs, err := check.validateBytes(ctx, bytes)
 	return len(errs) == 0, errs, err
 }
 
@@ -315,10 +411,17 @@ func (check SchemaCheck) CheckObject(ctx context.Context, obj any) (bool, []json
 	if err != nil {
 		return false, nil, err
 	}
-	errs, err := check.Validator.ValidateBytes(ctx, bytes)
+	errs, err := check.validateBytes(ctx, bytes)
 	return len(errs) == 0, errs, err
 }
 
+func (check SchemaCheck) validateBytes(ctx context.Context, bytes []byte) ([]jsonschema.KeyError, error) {
+	if check.compiled != nil {
+		return check.compiled.validateBytes(ctx, bytes)
+	}
+	return check.Validator.ValidateBytes(ctx, bytes)
+}
+
 // CheckAdditionalObjects looks for an object that passes the specified additional schema
 func (check SchemaCheck) CheckAdditionalObjects(ctx context.Context, groupkind string, objects []any) (bool, error) {
 	val, ok := check.AdditionalValidators[groupkind]
@@ -330,7 +433,12 @@ func (check SchemaCheck) CheckAdditionalObjects(ctx context.Context, groupkind s
 		if err != nil {
 			return false, err
 		}
-		errs, err := val.ValidateBytes(ctx, bytes)
+		var errs []jsonschema.KeyError
+		if compiled, ok := check.additionalCompiled[groupkind]; ok {
+			errs, err = compiled.validateBytes(ctx, bytes)
+		} else {
+			errs, err = val.ValidateBytes(ctx, bytes)
+		}
 		if err != nil {
 			return false, err
 		}
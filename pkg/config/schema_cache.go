@@ -0,0 +1,104 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/qri-io/jsonschema"
+)
+
+// maxTemplatedSchemas bounds how many rendered schemas are kept for each templated check
+const maxTemplatedSchemas = 1000
+
+// compiledSchema is a JSON schema that has been parsed once, and can be validated against concurrently.
+// jsonschema.Schema registers itself on first use and resolves references lazily while validating, so
+// registration happens up front and schemas with references are validated one at a time.
+type compiledSchema struct {
+	schema  *jsonschema.Schema
+	empty   bool
+	hasRefs bool
+	mutex   sync.Mutex
+}
+
+func compileSchema(schemaString string) (*compiledSchema, error) {
+	compiled := &compiledSchema{schema: &jsonschema.Schema{}}
+	if err := UnmarshalYAMLOrJSON([]byte(schemaString), compiled.schema); err != nil {
+		return nil, err
+	}
+	schemaBytes, err := json.Marshal(compiled.schema)
+	if err != nil {
+		return nil, err
+	}
+	compiled.empty = isEmptySchema(string(schemaBytes))
+	compiled.hasRefs = hasSchemaRefs(string(schemaBytes))
+	compiled.schema.Register("", &jsonschema.SchemaRegistry{})
+	return compiled, nil
+}
+
+func (c *compiledSchema) validateBytes(ctx context.Context, bytes []byte) ([]jsonschema.KeyError, error) {
+	if c.hasRefs {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+	}
+	return c.schema.ValidateBytes(ctx, bytes)
+}
+
+// hasSchemaRefs returns true if a schema uses a keyword that is resolved while validating
+func hasSchemaRefs(schemaJSON string) bool {
+	return strings.Contains(schemaJSON, `"$ref"`) || strings.Contains(schemaJSON, `"$recursiveRef"`)
+}
+
+func isEmptySchema(schemaJSON string) bool {
+	return schemaJSON == "" || schemaJSON == "null" || schemaJSON == "{}"
+}
+
+// isTemplated returns true if a schema string contains Go template actions
+func isTemplated(schemaString string) bool {
+	return strings.Contains(schemaString, "{{")
+}
+
+// schemaCache memoizes the schemas rendered from a templated check, keyed by their text
+type schemaCache struct {
+	mutex   sync.Mutex
+	schemas map[string]*compiledSchema
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{schemas: map[string]*compiledSchema{}}
+}
+
+func (c *schemaCache) get(schemaString string) (*compiledSchema, error) {
+	if c == nil {
+		return compileSchema(schemaString)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if compiled, ok := c.schemas[schemaString]; ok {
+		return compiled, nil
+	}
+	compiled, err := compileSchema(schemaString)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.schemas) >= maxTemplatedSchemas {
+		c.schemas = map[string]*compiledSchema{}
+	}
+	c.schemas[schemaString] = compiled
+	return compiled, nil
+}
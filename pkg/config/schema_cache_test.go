@@ -0,0 +1,102 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonTemplatedChecksAreCompiledOnce(t *testing.T) {
+	check := BuiltInChecks["hostIPCSet"]
+	assert.Nil(t, check.templates)
+	if !assert.NotNil(t, check.compiled) {
+		return
+	}
+
+	templated, err := check.TemplateForResource(map[string]any{"metadata": map[string]any{"name": "foo"}})
+	assert.NoError(t, err)
+	assert.Same(t, check.compiled, templated.compiled)
+
+	passes, _, err := templated.CheckObject(context.TODO(), map[string]any{"hostIPC": true})
+	assert.NoError(t, err)
+	assert.False(t, passes)
+}
+
+func TestTemplatedChecksAreMemoized(t *testing.T) {
+	check, err := ParseCheck("foo", []byte(`
+target: Controller
+schema:
+  '$schema': http://json-schema.org/draft-07/schema
+  type: object
+  properties:
+    metadata:
+      properties:
+        name:
+          const: "{{ .metadata.name }}"
+`))
+	assert.NoError(t, err)
+	assert.NotNil(t, check.templates)
+	assert.Nil(t, check.compiled)
+
+	first, err := check.TemplateForResource(map[string]any{"metadata": map[string]any{"name": "foo"}})
+	assert.NoError(t, err)
+	again, err := check.TemplateForResource(map[string]any{"metadata": map[string]any{"name": "foo"}})
+	assert.NoError(t, err)
+	other, err := check.TemplateForResource(map[string]any{"metadata": map[string]any{"name": "bar"}})
+	assert.NoError(t, err)
+	assert.Same(t, first.compiled, again.compiled)
+	assert.NotSame(t, first.compiled, other.compiled)
+	assert.Len(t, check.templateCache.schemas, 2)
+
+	passes, _, err := other.CheckObject(context.TODO(), map[string]any{"metadata": map[string]any{"name": "bar"}})
+	assert.NoError(t, err)
+	assert.True(t, passes)
+	passes, _, err = first.CheckObject(context.TODO(), map[string]any{"metadata": map[string]any{"name": "bar"}})
+	assert.NoError(t, err)
+	assert.False(t, passes)
+}
+
+func TestCompiledSchemasValidateConcurrently(t *testing.T) {
+	podSpec := map[string]any{
+		"hostIPC":         true,
+		"securityContext": map[string]any{"runAsNonRoot": true},
+		"containers":      []any{map[string]any{"securityContext": map[string]any{"runAsUser": 0}}},
+	}
+	for _, id := range []string{"hostIPCSet", "runAsRootAllowed"} {
+		check := BuiltInChecks[id]
+		if !assert.NotNil(t, check.compiled) {
+			return
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, err := check.CheckObject(context.TODO(), podSpec)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestInvalidSchemaFailsParsing(t *testing.T) {
+	_, err := ParseCheck("foo", []byte(`schemaString: "{not json"`))
+	assert.Error(t, err)
+}
@@ -32,3 +32,7 @@ const (
 func (severity *Severity) IsActionable() bool {
 	return *severity == SeverityWarning || *severity == SeverityDanger
 }
+
+func (severity Severity) isValid() bool {
+	return severity == SeverityIgnore || severity == SeverityWarning || severity == SeverityDanger
+}
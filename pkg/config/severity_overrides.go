@@ -0,0 +1,91 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SeverityOverride changes the severity of checks for the resources it matches. Every field that is set must match.
+type SeverityOverride struct {
+	// Namespaces are glob patterns, e.g. prod-*
+	Namespaces []string `json:"namespaces,omitempty"`
+	// NamespaceSelector matches the labels of the workload's namespace
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Selector matches the workload's labels
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	Kinds    []string              `json:"kinds,omitempty"`
+	// Checks maps check IDs to the severity to use instead of the one in Configuration.Checks
+	Checks map[string]Severity `json:"checks"`
+}
+
+// GetSeverity returns the severity of a check for a subject. Severity overrides that match the subject are
+// applied in order, so later overrides take precedence. Checks that aren't configured are ignored.
+func (conf Configuration) GetSeverity(ruleID string, subject ExemptionSubject) Severity {
+	severity, ok := conf.Checks[ruleID]
+	if !ok {
+		return SeverityIgnore
+	}
+	for _, override := range conf.SeverityOverrides {
+		overrideSeverity, ok := override.Checks[ruleID]
+		if ok && override.Matches(subject) {
+			severity = overrideSeverity
+		}
+	}
+	return severity
+}
+
+// Matches returns true if the override applies to a subject
+func (override SeverityOverride) Matches(subject ExemptionSubject) bool {
+	if len(override.Kinds) > 0 && !slices.Contains(override.Kinds, subject.Kind) {
+		return false
+	}
+	if len(override.Namespaces) > 0 && !isExemptionGlobMatched(override.Namespaces, subject.Object.GetNamespace()) {
+		return false
+	}
+	if !isExemptionSelectorMatched(override.Selector, subject.Object.GetLabels()) {
+		return false
+	}
+	if override.NamespaceSelector != nil {
+		if subject.Namespace == nil || !isExemptionSelectorMatched(override.NamespaceSelector, subject.Namespace.GetLabels()) {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks that the override's patterns, selectors and severities can be parsed
+func (override SeverityOverride) validate() error {
+	for _, glob := range override.Namespaces {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q: %w", glob, err)
+		}
+	}
+	for _, selector := range []*metav1.LabelSelector{override.NamespaceSelector, override.Selector} {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			return err
+		}
+	}
+	for checkID, severity := range override.Checks {
+		if !severity.isValid() {
+			return fmt.Errorf("invalid severity %q for check %s", severity, checkID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var confSeverityOverrides = `
+checks:
+  runAsRootAllowed: warning
+  priorityClassNotSet: ignore
+severityOverrides:
+  - namespaces:
+      - prod-*
+    checks:
+      runAsRootAllowed: danger
+  - namespaces:
+      - dev-*
+    checks:
+      runAsRootAllowed: ignore
+  - selector:
+      matchLabels:
+        tier: critical
+    kinds:
+      - Deployment
+    checks:
+      runAsRootAllowed: danger
+      priorityClassNotSet: danger
+`
+
+func TestSeverityOverrides(t *testing.T) {
+	parsedConf, err := Parse([]byte(confSeverityOverrides))
+	assert.NoError(t, err)
+
+	subject := func(kind, namespace string, labels map[string]string) ExemptionSubject {
+		obj := createMeta(namespace, "web")
+		obj.SetLabels(labels)
+		return ExemptionSubject{Kind: kind, Object: obj}
+	}
+	assert.Equal(t, SeverityWarning, parsedConf.GetSeverity("runAsRootAllowed", subject("Deployment", "default", nil)))
+	assert.Equal(t, SeverityDanger, parsedConf.GetSeverity("runAsRootAllowed", subject("Deployment", "prod-eu", nil)))
+	assert.Equal(t, SeverityIgnore, parsedConf.GetSeverity("runAsRootAllowed", subject("Deployment", "dev-eu", nil)))
+	assert.False(t, parsedConf.IsActionableFor("runAsRootAllowed", subject("Deployment", "dev-eu", nil)))
+
+	critical := map[string]string{"tier": "critical"}
+	assert.Equal(t, SeverityDanger, parsedConf.GetSeverity("runAsRootAllowed", subject("Deployment", "dev-eu", critical)))
+	assert.Equal(t, SeverityDanger, parsedConf.GetSeverity("priorityClassNotSet", subject("Deployment", "default", critical)))
+	assert.Equal(t, SeverityIgnore, parsedConf.GetSeverity("priorityClassNotSet", subject("StatefulSet", "default", critical)))
+	assert.Equal(t, SeverityIgnore, parsedConf.GetSeverity("hostIPCSet", subject("Deployment", "default", critical)))
+}
+
+func TestInvalidSeverityOverrides(t *testing.T) {
+	_, err := Parse([]byte(`
+checks:
+  runAsRootAllowed: warning
+severityOverrides:
+  - checks:
+      runAsRootAllowed: critical
+`))
+	assert.EqualError(t, err, `severity override 0: invalid severity "critical" for check runAsRootAllowed`)
+
+	_, err = Parse([]byte(`
+checks:
+  runAsRootAllowed: warning
+severityOverrides:
+  - checks:
+      hostIPCSet: danger
+`))
+	assert.EqualError(t, err, "severity override 0: check hostIPCSet has no severity in checks")
+}
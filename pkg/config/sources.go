@@ -0,0 +1,171 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSourceDefault is the source of settings that come from the default config
+const ConfigSourceDefault = "default"
+
+// ConfigSources records where each setting of a configuration came from, e.g. "default",
+// "file config.yaml", "url https://example.com/polaris.yaml" or "flag --checks". Settings are keyed
+// by their name, e.g. displayName. Checks and custom checks are keyed by ID, e.g. checks.hostIPCSet,
+// and exemptions, severity overrides and mutations by index, e.g. exemptions[0].
+type ConfigSources map[string]string
+
+// SetFlag records that a setting was set by a command line flag
+func (sources ConfigSources) SetFlag(key, flag string) {
+	sources[key] = "flag --" + flag
+}
+
+// record sets the source of a top-level config value, by ID for checks and custom checks, and by
+// index for lists
+func (sources ConfigSources) record(key string, value any, source string) {
+	switch values := value.(type) {
+	case map[string]any:
+		if slices.Contains(mergedConfigKeys, key) {
+			for id := range values {
+				sources[key+"."+id] = source
+			}
+			return
+		}
+	case []any:
+		for idx := range values {
+			sources[fmt.Sprintf("%s[%d]", key, idx)] = source
+		}
+		return
+	}
+	sources[key] = source
+}
+
+// clear removes the sources of a top-level config value, along with its checks or list items
+func (sources ConfigSources) clear(key string) {
+	maps.DeleteFunc(sources, func(k, _ string) bool {
+		return k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(k, key+"[")
+	})
+}
+
+// overlay records the sources of a config that was merged on top of another one. Maps are merged by
+// key, so only lists and other values replace what was there before.
+func (sources ConfigSources) overlay(values map[string]any, overrides ConfigSources) {
+	for key, value := range values {
+		if _, ok := value.(map[string]any); !ok {
+			sources.clear(key)
+		}
+	}
+	maps.Copy(sources, overrides)
+}
+
+// getDefaultConfigSources returns the sources of the default config
+func getDefaultConfigSources() (ConfigSources, error) {
+	values, err := unmarshalConfigValues(defaultConfig)
+	if err != nil {
+		return nil, err
+	}
+	sources := ConfigSources{}
+	for key, value := range values {
+		sources.record(key, value, ConfigSourceDefault)
+	}
+	return sources, nil
+}
+
+// Hash returns a hash of the configuration, which identifies the settings an audit ran with
+func (conf Configuration) Hash() (string, error) {
+	content, err := json.Marshal(conf)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// MarshalYAMLWithSources marshals a configuration to YAML, with a comment next to each setting saying
+// where it came from
+func MarshalYAMLWithSources(conf Configuration, sources ConfigSources) ([]byte, error) {
+	content, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	resetYAMLStyle(&document)
+	if len(document.Content) == 1 {
+		annotateYAMLSources(document.Content[0], sources, "")
+	}
+	var output bytes.Buffer
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// resetYAMLStyle switches YAML that was parsed from JSON to block style
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && node.Value == "" {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+func annotateYAMLSources(node *yaml.Node, sources ConfigSources, prefix string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			key, value := node.Content[idx], node.Content[idx+1]
+			path := prefix + key.Value
+			if prefix != "" {
+				path = prefix + "." + key.Value
+			}
+			if source, ok := sources[path]; ok {
+				key.LineComment = source
+			} else if prefix == "" {
+				annotateYAMLSources(value, sources, path)
+			}
+		}
+	case yaml.SequenceNode:
+		for idx, item := range node.Content {
+			source, ok := sources[fmt.Sprintf("%s[%d]", prefix, idx)]
+			if !ok {
+				continue
+			}
+			if item.Kind == yaml.ScalarNode {
+				item.LineComment = source
+			} else {
+				item.HeadComment = source
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigSources(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfigFile(t, filepath.Join(dir, "base.yaml"), `
+displayName: base
+checks:
+  hostIPCSet: danger
+exemptions:
+  - namespace: kube-system
+`)
+	app := writeConfigFile(t, filepath.Join(dir, "app.yaml"), `
+extends:
+  - base.yaml
+displayName: app
+checks:
+  hostPIDSet: warning
+exemptions:
+  - namespace: monitoring
+`)
+
+	_, sources, err := MergeConfigAndParseFilesWithSources([]string{app}, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, ConfigSources{
+		"displayName":       "file " + app,
+		"checks.hostIPCSet": "file " + base,
+		"checks.hostPIDSet": "file " + app,
+		"exemptions[0]":     "file " + base,
+		"exemptions[1]":     "file " + app,
+	}, sources)
+
+	// Merging with the default config keeps the sources of the default checks, but lists are replaced
+	_, sources, err = MergeConfigAndParseFilesWithSources([]string{app}, nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "file "+app, sources["checks.hostPIDSet"])
+	assert.Equal(t, ConfigSourceDefault, sources["checks.runAsRootAllowed"])
+	assert.Equal(t, "file "+base, sources["exemptions[0]"])
+	assert.Equal(t, "file "+app, sources["exemptions[1]"])
+	assert.NotContains(t, sources, "exemptions[2]")
+
+	_, sources, err = MergeConfigAndParseFilesWithSources(nil, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, ConfigSourceDefault, sources["checks.runAsRootAllowed"])
+	assert.Equal(t, ConfigSourceDefault, sources["exemptions[0]"])
+}
+
+func TestMarshalYAMLWithSources(t *testing.T) {
+	conf := Configuration{
+		DisplayName: "app",
+		Checks:      map[string]Severity{"hostIPCSet": SeverityDanger},
+		Exemptions:  []Exemption{{Namespace: "kube-system"}},
+		Mutations:   []string{"hostIPCSet"},
+	}
+	sources := ConfigSources{
+		"displayName":       "file app.yaml",
+		"checks.hostIPCSet": ConfigSourceDefault,
+		"exemptions[0]":     "url https://example.com/polaris.yaml",
+		"mutations[0]":      "file app.yaml",
+	}
+	sources.SetFlag("namespace", "namespace")
+	content, err := MarshalYAMLWithSources(conf, sources)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "displayName: app # file app.yaml\n")
+	assert.Contains(t, string(content), "checks:\n  hostIPCSet: danger # default\n")
+	assert.Contains(t, string(content), "exemptions:\n  # url https://example.com/polaris.yaml\n  - rules: null\n")
+	assert.Contains(t, string(content), "mutations:\n  - hostIPCSet # file app.yaml\n")
+	assert.Contains(t, string(content), `namespace: "" # flag --namespace`)
+
+	content, err = MarshalYAMLWithSources(conf, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "#")
+}
+
+func TestConfigHash(t *testing.T) {
+	conf := Configuration{Checks: map[string]Severity{"hostIPCSet": SeverityDanger}}
+	hash, err := conf.Hash()
+	assert.NoError(t, err)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", hash)
+	sameHash, err := conf.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, hash, sameHash)
+
+	conf.Checks["hostIPCSet"] = SeverityWarning
+	otherHash, err := conf.Hash()
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, otherHash)
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "sync/atomic"
+
+// Store holds the current configuration of a long-running server, which can be replaced while requests
+// are using it. Configurations are swapped whole, so each request sees a single, consistent configuration.
+type Store struct {
+	current atomic.Pointer[Configuration]
+}
+
+// NewStore creates a Store holding a configuration
+func NewStore(conf Configuration) *Store {
+	store := &Store{}
+	store.Set(conf)
+	return store
+}
+
+// Get returns the current configuration. It must not be modified, since other requests may be using it.
+func (s *Store) Get() Configuration {
+	return *s.current.Load()
+}
+
+// Set replaces the current configuration
+func (s *Store) Set(conf Configuration) {
+	s.current.Store(&conf)
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/qri-io/jsonschema"
+)
+
+// wasmModule is a compiled WebAssembly check
+type wasmModule interface {
+	// eval calls the module's check function with a JSON input, returning its JSON output
+	eval(ctx context.Context, input []byte) ([]byte, error)
+}
+
+// wasmOutput is what a WebAssembly check returns
+type wasmOutput struct {
+	Passes  bool   `json:"passes"`
+	Message string `json:"message"`
+	Details []struct {
+		PropertyPath string `json:"propertyPath"`
+		InvalidValue any    `json:"invalidValue"`
+		Message      string `json:"message"`
+	} `json:"details"`
+}
+
+// initializeWASM loads and compiles the check's WebAssembly module, if it has one
+func (check *SchemaCheck) initializeWASM() error {
+	check.wasmModule = nil
+	if check.WASM == "" {
+		return nil
+	}
+	content := check.wasmContent
+	var err error
+	if content == nil {
+		if content, err = readConfigLocation(check.WASM); err != nil {
+			return fmt.Errorf("check %s: reading wasm module: %w", check.ID, err)
+		}
+	}
+	check.wasmModule, err = newWazeroModule(content)
+	if err != nil {
+		return fmt.Errorf("check %s: compiling wasm module %s: %w", check.ID, check.WASM, err)
+	}
+	return nil
+}
+
+// HasWASM returns true if the check has a WebAssembly module to run
+func (check SchemaCheck) HasWASM() bool {
+	return check.WASM != ""
+}
+
+// CheckWASM runs the check's WebAssembly module, returning whether it passes, the message to use instead
+// of the check's own (if any), and details about the failure
+func (check SchemaCheck) CheckWASM(ctx context.Context, input CheckInput) (bool, string, []jsonschema.KeyError, error) {
+	if check.wasmModule == nil {
+		// The check wasn't initialized, e.g. because it was built in code
+		if err := check.initializeWASM(); err != nil {
+			return false, "", nil, err
+		}
+		if check.wasmModule == nil {
+			return true, "", nil, nil
+		}
+	}
+	inputBytes, err := json.Marshal(input.variables())
+	if err != nil {
+		return false, "", nil, err
+	}
+	outputBytes, err := check.wasmModule.eval(ctx, inputBytes)
+	if err != nil {
+		return false, "", nil, fmt.Errorf("check %s: running wasm module: %w", check.ID, err)
+	}
+	var output wasmOutput
+	if err := json.Unmarshal(outputBytes, &output); err != nil {
+		return false, "", nil, fmt.Errorf("check %s: wasm module returned invalid output: %w", check.ID, err)
+	}
+	issues := make([]jsonschema.KeyError, len(output.Details))
+	for i, detail := range output.Details {
+		issues[i] = jsonschema.KeyError{
+			PropertyPath: detail.PropertyPath,
+			InvalidValue: detail.InvalidValue,
+			Message:      detail.Message,
+		}
+	}
+	return output.Passes, output.Message, issues, nil
+}
+
+// resolveWASMLocations resolves the wasm modules of a config's custom checks relative to the config's
+// own location, like the configs it extends
+func resolveWASMLocations(location string, layer map[string]any) {
+	customChecks, _ := layer["customChecks"].(map[string]any)
+	for _, check := range customChecks {
+		checkValues, _ := check.(map[string]any)
+		if wasm, ok := checkValues["wasm"].(string); ok && wasm != "" {
+			checkValues["wasm"] = resolveConfigLocation(location, wasm)
+		}
+	}
+}
+
+// resolveBundledWASM resolves the wasm module of a check from a bundle relative to the bundle's root. For
+// a tarball, the module is read from the tarball's files.
+func resolveBundledWASM(check *SchemaCheck, bundleLocation string, tarballFiles map[string][]byte) error {
+	if check.WASM == "" || isConfigURL(check.WASM) || filepath.IsAbs(check.WASM) {
+		return nil
+	}
+	if tarballFiles == nil {
+		check.WASM = filepath.Join(bundleLocation, check.WASM)
+		return nil
+	}
+	content, ok := tarballFiles[path.Clean(filepath.ToSlash(check.WASM))]
+	if !ok {
+		return fmt.Errorf("wasm module %s isn't in the bundle", check.WASM)
+	}
+	check.wasmContent = content
+	return nil
+}
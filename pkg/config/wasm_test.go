@@ -0,0 +1,117 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var wasmConf = `
+checks:
+  wasmCheck: warning
+customChecks:
+  wasmCheck:
+    successMessage: The module passed
+    failureMessage: The module failed
+    category: Security
+    target: Container
+    wasm: checks/check.wasm
+`
+
+func TestWASMLocation(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "checks"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "checks", "check.wasm"), buildTestWASMModule(`{"passes":true}`, false), 0644))
+	app := writeConfigFile(t, filepath.Join(dir, "app.yaml"), wasmConf)
+
+	// Modules are found relative to the config that references them
+	layers, err := loadConfigLayers([]string{app})
+	assert.NoError(t, err)
+	wasm := layers[0].values["customChecks"].(map[string]any)["wasmCheck"].(map[string]any)["wasm"]
+	assert.Equal(t, filepath.Join(dir, "checks", "check.wasm"), wasm)
+}
+
+// buildTestWASMModule assembles a module that follows the wasm check ABI, whose check function returns
+// a constant output. If loop is set, check loops forever instead.
+func buildTestWASMModule(output string, loop bool) []byte {
+	const outputPtr = 2048
+	section := func(id byte, content ...byte) []byte {
+		return append(append([]byte{id}, uleb128(uint64(len(content)))...), content...)
+	}
+	name := func(s string) []byte {
+		return append(uleb128(uint64(len(s))), s...)
+	}
+	concat := func(parts ...[]byte) []byte {
+		var all []byte
+		for _, part := range parts {
+			all = append(all, part...)
+		}
+		return all
+	}
+
+	allocateBody := []byte{0x00, 0x41, 0x80, 0x08, 0x0b} // i32.const 1024
+	checkBody := []byte{0x00}
+	if loop {
+		checkBody = append(checkBody, 0x03, 0x40, 0x0c, 0x00, 0x0b) // loop, br 0, end
+	}
+	checkBody = append(checkBody, 0x42) // i64.const
+	checkBody = append(checkBody, sleb128(outputPtr<<32|int64(len(output)))...)
+	checkBody = append(checkBody, 0x0b)
+
+	return concat(
+		[]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		// allocate(i32) i32, check(i32, i32) i64
+		section(1, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e),
+		section(3, 0x02, 0x00, 0x01),
+		section(5, 0x01, 0x00, 0x01),
+		section(7, concat([]byte{0x03},
+			name("memory"), []byte{0x02, 0x00},
+			name("allocate"), []byte{0x00, 0x00},
+			name("check"), []byte{0x00, 0x01})...),
+		section(10, concat([]byte{0x02},
+			uleb128(uint64(len(allocateBody))), allocateBody,
+			uleb128(uint64(len(checkBody))), checkBody)...),
+		section(11, concat([]byte{0x01, 0x00, 0x41}, sleb128(outputPtr), []byte{0x0b},
+			name(output))...),
+	)
+}
+
+func uleb128(value uint64) []byte {
+	var encoded []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value == 0 {
+			return append(encoded, b)
+		}
+		encoded = append(encoded, b|0x80)
+	}
+}
+
+func sleb128(value int64) []byte {
+	var encoded []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if (value == 0 && b&0x40 == 0) || (value == -1 && b&0x40 != 0) {
+			return append(encoded, b)
+		}
+		encoded = append(encoded, b|0x80)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const (
+	// wasmMemoryLimitPages limits each module to 64 MiB of memory
+	wasmMemoryLimitPages = 1024
+	// wasmTimeout limits how long a module can run for each resource
+	wasmTimeout = 5 * time.Second
+)
+
+// wazeroModule runs a WebAssembly module with the wazero runtime. Modules can use WASI, but don't have
+// access to the filesystem, network, environment or clock.
+type wazeroModule struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+}
+
+func newWazeroModule(content []byte) (wasmModule, error) {
+	ctx := context.Background()
+	wasmRuntime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(wasmMemoryLimitPages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, wasmRuntime); err != nil {
+		wasmRuntime.Close(ctx)
+		return nil, err
+	}
+	compiled, err := wasmRuntime.CompileModule(ctx, content)
+	if err != nil {
+		wasmRuntime.Close(ctx)
+		return nil, err
+	}
+	for _, name := range []string{"allocate", "check"} {
+		if _, ok := compiled.ExportedFunctions()[name]; !ok {
+			wasmRuntime.Close(ctx)
+			return nil, fmt.Errorf("module doesn't export a %s function", name)
+		}
+	}
+	module := &wazeroModule{runtime: wasmRuntime, compiled: compiled}
+	// Checks are replaced when the config is reloaded
+	runtime.SetFinalizer(module, func(m *wazeroModule) {
+		m.runtime.Close(context.Background())
+	})
+	return module, nil
+}
+
+// eval writes the input to memory from allocate(len), then calls check(ptr, len), which returns the
+// pointer and length of its output packed into the high and low 32 bits
+func (m *wazeroModule) eval(ctx context.Context, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, wasmTimeout)
+	defer cancel()
+	// Each resource gets a new instance, so modules can't keep state between resources
+	module, err := m.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, err
+	}
+	defer module.Close(ctx)
+	if module.Memory() == nil {
+		return nil, fmt.Errorf("module doesn't export its memory")
+	}
+
+	results, err := module.ExportedFunction("allocate").Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("allocate: %w", err)
+	}
+	inputPtr := uint32(results[0])
+	if !module.Memory().Write(inputPtr, input) {
+		return nil, fmt.Errorf("allocate returned %d, which is out of range", inputPtr)
+	}
+	results, err = module.ExportedFunction("check").Call(ctx, uint64(inputPtr), uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("check: %w", err)
+	}
+	outputPtr, outputLen := uint32(results[0]>>32), uint32(results[0])
+	output, ok := module.Memory().Read(outputPtr, outputLen)
+	if !ok {
+		return nil, fmt.Errorf("check returned %d bytes at %d, which is out of range", outputLen, outputPtr)
+	}
+	// The memory is freed when the instance is closed
+	return bytes.Clone(output), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qri-io/jsonschema"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseWASMCheck(t *testing.T, module []byte) (SchemaCheck, error) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "checks"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "checks", "check.wasm"), module, 0644))
+	c, err := MergeConfigAndParseFile(writeConfigFile(t, filepath.Join(dir, "app.yaml"), wasmConf), false)
+	return c.CustomChecks["wasmCheck"], err
+}
+
+func TestCheckWASM(t *testing.T) {
+	input := CheckInput{Container: map[string]any{"image": "docker.io/foo"}}
+	check, err := parseWASMCheck(t, buildTestWASMModule(`{"passes":true}`, false))
+	assert.NoError(t, err)
+	passes, message, issues, err := check.CheckWASM(context.Background(), input)
+	assert.NoError(t, err)
+	assert.True(t, passes)
+	assert.Equal(t, "", message)
+	assert.Empty(t, issues)
+
+	check, err = parseWASMCheck(t, buildTestWASMModule(`{"passes":false,"message":"Image isn't allowed","details":[{"propertyPath":"/image","invalidValue":"docker.io/foo","message":"isn't allowed"}]}`, false))
+	assert.NoError(t, err)
+	passes, message, issues, err = check.CheckWASM(context.Background(), input)
+	assert.NoError(t, err)
+	assert.False(t, passes)
+	assert.Equal(t, "Image isn't allowed", message)
+	assert.Equal(t, []jsonschema.KeyError{{PropertyPath: "/image", InvalidValue: "docker.io/foo", Message: "isn't allowed"}}, issues)
+
+	check, err = parseWASMCheck(t, buildTestWASMModule(`not json`, false))
+	assert.NoError(t, err)
+	_, _, _, err = check.CheckWASM(context.Background(), input)
+	assert.ErrorContains(t, err, "check wasmCheck: wasm module returned invalid output")
+
+	_, err = parseWASMCheck(t, []byte("not wasm"))
+	assert.ErrorContains(t, err, "check wasmCheck: compiling wasm module")
+}
+
+func TestCheckWASMTimeout(t *testing.T) {
+	check, err := parseWASMCheck(t, buildTestWASMModule(`{"passes":true}`, true))
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, _, _, err = check.CheckWASM(ctx, CheckInput{})
+	assert.ErrorContains(t, err, "check wasmCheck: running wasm module")
+}
+
+func TestBundledWASM(t *testing.T) {
+	input := CheckInput{Container: map[string]any{"image": "docker.io/foo"}}
+	bundledWASMCheck := "failureMessage: The module failed\ntarget: Container\nwasm: modules/check.wasm\n"
+	module := buildTestWASMModule(`{"passes":false}`, false)
+
+	// Modules are found relative to the bundle's root, not the working directory
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, "security", "wasmCheck.yaml"), bundledWASMCheck)
+	writeConfigFile(t, filepath.Join(dir, "modules", "check.wasm"), string(module))
+	c := Configuration{}
+	_, err := c.LoadCheckPaths([]string{dir})
+	if assert.NoError(t, err) {
+		assert.Equal(t, filepath.Join(dir, "modules", "check.wasm"), c.CustomChecks["wasmCheck"].WASM)
+		passes, _, _, err := c.CustomChecks["wasmCheck"].CheckWASM(context.Background(), input)
+		assert.NoError(t, err)
+		assert.False(t, passes)
+	}
+
+	// and inside a tarball, they're read from the tarball
+	tarball := filepath.Join(t.TempDir(), "acme.tar.gz")
+	assert.NoError(t, os.WriteFile(tarball, buildCheckTarball(t, map[string]string{
+		"acme/security/wasmCheck.yaml": bundledWASMCheck,
+		"acme/modules/check.wasm":      string(module),
+	}), 0644))
+	c = Configuration{}
+	_, err = c.LoadCheckPaths([]string{tarball})
+	if assert.NoError(t, err) {
+		passes, _, _, err := c.CustomChecks["wasmCheck"].CheckWASM(context.Background(), input)
+		assert.NoError(t, err)
+		assert.False(t, passes)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.tar.gz")
+	assert.NoError(t, os.WriteFile(missing, buildCheckTarball(t, map[string]string{"security/wasmCheck.yaml": bundledWASMCheck}), 0644))
+	_, err = (&Configuration{}).LoadCheckPaths([]string{missing})
+	assert.EqualError(t, err, "loading checks from "+missing+": check wasmCheck.yaml: wasm module modules/check.wasm isn't in the bundle")
+}
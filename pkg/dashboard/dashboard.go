@@ -140,8 +140,27 @@ func stripUnselectedNamespaces(data *validator.AuditData, selectedNamespaces []s
 	data.Results = newResults
 }
 
-// GetRouter returns a mux router serving all routes necessary for the dashboard
-func GetRouter(ctx context.Context, c config.Configuration, auditPath string, port int, basePath string, auditData *validator.AuditData) (*mux.Router, error) {
+// GetRouter returns a mux router serving all routes necessary for the dashboard. Each request uses the
+// configuration that's current in the store.
+func GetRouter(ctx context.Context, c *config.Store, auditPath string, port int, basePath string, auditData *validator.AuditData) (*mux.Router, error) {
+	getResources := func(ctx context.Context) (*kube.ResourceProvider, error) {
+		return kube.CreateResourceProvider(ctx, auditPath, "", c.Get())
+	}
+	return getRouter(ctx, c, basePath, auditData, getResources, validator.RunAudit)
+}
+
+// GetCachedRouter returns a mux router for the dashboard which audits the resources in an informer cache,
+// only re-validating the ones that changed since the previous request
+func GetCachedRouter(ctx context.Context, c *config.Store, provider *kube.InformerResourceProvider, basePath string) (*mux.Router, error) {
+	getResources := func(context.Context) (*kube.ResourceProvider, error) {
+		return provider.GetResourceProvider()
+	}
+	return getRouter(ctx, c, basePath, nil, getResources, validator.NewIncrementalAuditor().RunAudit)
+}
+
+func getRouter(ctx context.Context, c *config.Store, basePath string, auditData *validator.AuditData,
+	getResources func(context.Context) (*kube.ResourceProvider, error),
+	runAudit func(context.Context, config.Configuration, *kube.ResourceProvider) (validator.AuditData, error)) (*mux.Router, error) {
 	router := mux.NewRouter().PathPrefix(basePath).Subrouter()
 
 	assetsSubFS, err := fs.Sub(assetsFS, "assets")
@@ -167,9 +186,9 @@ func GetRouter(ctx context.Context, c config.Configuration, auditPath string, po
 	})
 
 	router.HandleFunc("/results.json", func(w http.ResponseWriter, r *http.Request) {
-		adjustedConf := getConfigForQuery(c, r.URL.Query())
+		adjustedConf := getConfigForQuery(c.Get(), r.URL.Query())
 		if auditData == nil {
-			k, err := kube.CreateResourceProvider(r.Context(), auditPath, "", c)
+			k, err := getResources(r.Context())
 			if err != nil {
 				logrus.Errorf("Error fetching Kubernetes resources %v", err)
 				http.Error(w, "Error fetching Kubernetes resources", http.StatusInternalServerError)
@@ -177,7 +196,7 @@ func GetRouter(ctx context.Context, c config.Configuration, auditPath string, po
 			}
 
 			var auditDataObj validator.AuditData
-			auditDataObj, err = validator.RunAudit(ctx, adjustedConf, k)
+			auditDataObj, err = runAudit(ctx, adjustedConf, k)
 			if err != nil {
 				http.Error(w, "Error Fetching Deployments", http.StatusInternalServerError)
 				return
@@ -200,11 +219,11 @@ func GetRouter(ctx context.Context, c config.Configuration, auditPath string, po
 			http.NotFound(w, r)
 			return
 		}
-		adjustedConf := getConfigForQuery(c, r.URL.Query())
+		adjustedConf := getConfigForQuery(c.Get(), r.URL.Query())
 
 		if auditData == nil {
 			logrus.Infof("Creating resource provider")
-			k, err := kube.CreateResourceProvider(r.Context(), auditPath, "", c)
+			k, err := getResources(r.Context())
 			if err != nil {
 				logrus.Errorf("Error fetching Kubernetes resources %v", err)
 				http.Error(w, "Error fetching Kubernetes resources", http.StatusInternalServerError)
@@ -213,7 +232,7 @@ func GetRouter(ctx context.Context, c config.Configuration, auditPath string, po
 
 			logrus.Infof("Running audit")
 			var auditData validator.AuditData
-			auditData, err = validator.RunAudit(ctx, adjustedConf, k)
+			auditData, err = runAudit(ctx, adjustedConf, k)
 			if err != nil {
 				logrus.Errorf("Error getting audit data: %v", err)
 				http.Error(w, "Error running audit", 500)
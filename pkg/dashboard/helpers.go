@@ -89,7 +89,9 @@ func getWeatherIcon(counts validator.CountSummary) string {
 
 func getResultClass(result validator.ResultMessage) string {
 	cls := string(result.Severity)
-	if result.Success {
+	if result.Exempted {
+		cls += " exempted"
+	} else if result.Success {
 		cls += " success"
 	} else {
 		cls += " failure"
@@ -113,7 +115,9 @@ func getWeatherText(counts validator.CountSummary) string {
 }
 
 func getIcon(rm validator.ResultMessage) string {
-	if rm.Success {
+	if rm.Exempted {
+		return "fas fa-eye-slash"
+	} else if rm.Success {
 		return "fas fa-check"
 	} else if rm.Severity == config.SeverityWarning {
 		return "fas fa-exclamation"
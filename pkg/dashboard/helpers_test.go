@@ -104,7 +104,7 @@ func TestGetResultClass(t *testing.T) {
 	input := validator.ResultMessage{
 		ID:       "",
 		Message:  "",
-		Details:  []string(nil),
+		Details:  []validator.ResultDetail(nil),
 		Success:  false,
 		Severity: "",
 		Category: "",
@@ -119,7 +119,7 @@ func TestGetResultClass(t *testing.T) {
 	input = validator.ResultMessage{
 		ID:       "",
 		Message:  "",
-		Details:  []string(nil),
+		Details:  []validator.ResultDetail(nil),
 		Success:  true,
 		Severity: "",
 		Category: "",
@@ -130,6 +130,9 @@ func TestGetResultClass(t *testing.T) {
 
 	assert.Equal(t, expectedOutput, actual)
 	assert.NotEqual(t, " failure", actual)
+
+	input.Exempted = true
+	assert.Equal(t, " exempted", getResultClass(input))
 }
 
 func TestGetWeatherText(t *testing.T) {
@@ -150,7 +153,7 @@ func TestGetIcon(t *testing.T) {
 	input := validator.ResultMessage{
 		ID:       "",
 		Message:  "",
-		Details:  []string(nil),
+		Details:  []validator.ResultDetail(nil),
 		Success:  false,
 		Severity: "",
 		Category: "",
@@ -165,7 +168,7 @@ func TestGetIcon(t *testing.T) {
 	input = validator.ResultMessage{
 		ID:       "",
 		Message:  "",
-		Details:  []string(nil),
+		Details:  []validator.ResultDetail(nil),
 		Success:  true,
 		Severity: "",
 		Category: "",
@@ -180,7 +183,7 @@ func TestGetIcon(t *testing.T) {
 	input = validator.ResultMessage{
 		ID:       "",
 		Message:  "",
-		Details:  []string(nil),
+		Details:  []validator.ResultDetail(nil),
 		Success:  false,
 		Severity: config.SeverityWarning,
 		Category: "",
@@ -191,6 +194,9 @@ func TestGetIcon(t *testing.T) {
 
 	assert.Equal(t, expectedOutput, actual)
 	assert.NotEqual(t, "fas fa-times", actual)
+
+	input.Exempted = true
+	assert.Equal(t, "fas fa-eye-slash", getIcon(input))
 }
 
 func TestGetCategoryLink(t *testing.T) {
@@ -0,0 +1,162 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+var configMapResource = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// PolarisConfigResource is the PolarisConfig custom resource, whose spec is a Polaris configuration
+var PolarisConfigResource = schema.GroupVersionResource{Group: "polaris.fairwinds.com", Version: "v1alpha1", Resource: "polarisconfigs"}
+
+// ConfigParser turns the contents of a ConfigMap or PolarisConfig into a configuration, e.g. merging it
+// with the default configuration and applying command line flags
+type ConfigParser func(rawBytes []byte) (conf.Configuration, error)
+
+// configWatcher keeps a config.Store up to date with a ConfigMap or PolarisConfig
+type configWatcher struct {
+	resource  schema.GroupVersionResource
+	namespace string
+	name      string
+	// key is the ConfigMap key the configuration is read from
+	key   string
+	store *conf.Store
+	parse ConfigParser
+}
+
+// WatchConfigMap loads the configuration from a ConfigMap key into a store, and keeps it up to date until
+// ctx is cancelled. Each version is parsed and validated before it's swapped in, and the current
+// configuration is kept if a version is invalid or the ConfigMap is deleted.
+func WatchConfigMap(ctx context.Context, dynamicClient dynamic.Interface, namespace, name, key string, store *conf.Store, parse ConfigParser) error {
+	return watchConfig(ctx, dynamicClient, &configWatcher{
+		resource:  configMapResource,
+		namespace: namespace,
+		name:      name,
+		key:       key,
+		store:     store,
+		parse:     parse,
+	})
+}
+
+// WatchPolarisConfig works like WatchConfigMap, loading the configuration from the spec of a PolarisConfig
+func WatchPolarisConfig(ctx context.Context, dynamicClient dynamic.Interface, namespace, name string, store *conf.Store, parse ConfigParser) error {
+	return watchConfig(ctx, dynamicClient, &configWatcher{
+		resource:  PolarisConfigResource,
+		namespace: namespace,
+		name:      name,
+		store:     store,
+		parse:     parse,
+	})
+}
+
+func watchConfig(ctx context.Context, dynamicClient dynamic.Interface, w *configWatcher) error {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, w.namespace, func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", w.name).String()
+	})
+	informer := factory.ForResource(w.resource).Informer()
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: w.update,
+		UpdateFunc: func(_, obj any) {
+			w.update(obj)
+		},
+		DeleteFunc: func(obj any) {
+			if w.matches(obj) {
+				logrus.Warnf("%s was deleted, keeping the current configuration", w)
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+	factory.Start(ctx.Done())
+	syncCtx, cancel := context.WithTimeout(ctx, informerSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.HasSynced) {
+		return fmt.Errorf("timed out waiting for %s informer to sync", w.resource.String())
+	}
+	if _, exists, _ := informer.GetStore().GetByKey(w.namespace + "/" + w.name); !exists {
+		logrus.Warnf("%s was not found, using the current configuration until it's created", w)
+	}
+	return nil
+}
+
+func (w *configWatcher) String() string {
+	if w.resource == configMapResource {
+		return fmt.Sprintf("ConfigMap %s/%s", w.namespace, w.name)
+	}
+	return fmt.Sprintf("PolarisConfig %s/%s", w.namespace, w.name)
+}
+
+func (w *configWatcher) matches(obj any) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	return ok && u.GetNamespace() == w.namespace && u.GetName() == w.name
+}
+
+// update parses a new version of the configuration, and swaps it in if it's valid
+func (w *configWatcher) update(obj any) {
+	if !w.matches(obj) {
+		return
+	}
+	u := obj.(*unstructured.Unstructured)
+	rawBytes, err := w.getContent(u)
+	if err == nil {
+		var c conf.Configuration
+		if c, err = w.parse(rawBytes); err == nil {
+			w.store.Set(c)
+			logrus.Infof("Loaded configuration from %s at resource version %s", w, u.GetResourceVersion())
+			return
+		}
+	}
+	logrus.Errorf("Keeping the current configuration, since %s is invalid at resource version %s: %v", w, u.GetResourceVersion(), err)
+}
+
+func (w *configWatcher) getContent(u *unstructured.Unstructured) ([]byte, error) {
+	if w.resource == configMapResource {
+		content, found, err := unstructured.NestedString(u.Object, "data", w.key)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("key %s was not found", w.key)
+		}
+		return []byte(content), nil
+	}
+	spec, found, err := unstructured.NestedMap(u.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("spec was not found")
+	}
+	return json.Marshal(spec)
+}
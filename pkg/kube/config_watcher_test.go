@@ -0,0 +1,126 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+)
+
+func newConfigTestClient(objects ...runtime.Object) *dynamicFake.FakeDynamicClient {
+	return dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMapResource:     "ConfigMapList",
+		PolarisConfigResource: "PolarisConfigList",
+	}, objects...)
+}
+
+func newConfigMap(data string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "polaris", "namespace": "polaris"},
+		"data":       map[string]any{"config.yaml": data},
+	}}
+}
+
+// watchTestConfig records each version of the configuration that's parsed, valid or not
+func watchTestConfig(parsed chan error) ConfigParser {
+	return func(rawBytes []byte) (conf.Configuration, error) {
+		c, err := conf.MergeConfigAndParse(rawBytes, nil, false)
+		parsed <- err
+		return c, err
+	}
+}
+
+func TestWatchConfigMap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := newConfigTestClient(newConfigMap("displayName: v1\nchecks:\n  hostIPCSet: danger\n"))
+	store := conf.NewStore(conf.Configuration{DisplayName: "flags"})
+	parsed := make(chan error, 10)
+
+	err := WatchConfigMap(ctx, client, "polaris", "polaris", "config.yaml", store, watchTestConfig(parsed))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return store.Get().DisplayName == "v1"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, <-parsed)
+	assert.Equal(t, conf.SeverityDanger, store.Get().Checks["hostIPCSet"])
+
+	// Invalid versions are rejected, and the current configuration is kept
+	_, err = client.Resource(configMapResource).Namespace("polaris").Update(ctx, newConfigMap("displayName: v2\nchecks:\n  hostIPCSet: critical\n"), metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	select {
+	case err := <-parsed:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the config to be reloaded")
+	}
+	assert.Equal(t, "v1", store.Get().DisplayName)
+
+	_, err = client.Resource(configMapResource).Namespace("polaris").Update(ctx, newConfigMap("displayName: v3\nchecks:\n  hostIPCSet: warning\n"), metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return store.Get().DisplayName == "v3"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, conf.SeverityWarning, store.Get().Checks["hostIPCSet"])
+
+	// Deleting the ConfigMap keeps the current configuration
+	err = client.Resource(configMapResource).Namespace("polaris").Delete(ctx, "polaris", metav1.DeleteOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v3", store.Get().DisplayName)
+}
+
+func TestWatchConfigMapMissing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := conf.NewStore(conf.Configuration{DisplayName: "flags"})
+
+	err := WatchConfigMap(ctx, newConfigTestClient(), "polaris", "polaris", "config.yaml", store, watchTestConfig(make(chan error, 10)))
+	assert.NoError(t, err)
+	assert.Equal(t, "flags", store.Get().DisplayName)
+}
+
+func TestWatchPolarisConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	polarisConfig := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "polaris.fairwinds.com/v1alpha1",
+		"kind":       "PolarisConfig",
+		"metadata":   map[string]any{"name": "polaris", "namespace": "polaris"},
+		"spec": map[string]any{
+			"displayName": "crd",
+			"checks":      map[string]any{"runAsRootAllowed": "warning"},
+		},
+	}}
+	store := conf.NewStore(conf.Configuration{})
+	parsed := make(chan error, 10)
+
+	err := WatchPolarisConfig(ctx, newConfigTestClient(polarisConfig), "polaris", "polaris", store, watchTestConfig(parsed))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return store.Get().DisplayName == "crd"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, conf.SeverityWarning, store.Get().Checks["runAsRootAllowed"])
+}
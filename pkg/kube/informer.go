@@ -0,0 +1,396 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+)
+
+// topControllerKinds are watched up front, so that controllers without any pods are still audited
+var topControllerKinds = []schema.GroupVersionKind{
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+	{Group: "batch", Version: "v1", Kind: "CronJob"},
+	{Group: "batch", Version: "v1", Kind: "Job"},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+}
+
+// informerSyncTimeout bounds how long we wait for an informer cache, e.g. when RBAC forbids listing a kind
+const informerSyncTimeout = time.Minute
+
+// listerRetryInterval is how long a kind that couldn't be watched is skipped before trying again
+const listerRetryInterval = 5 * time.Minute
+
+// InformerResourceProvider keeps an up-to-date cache of cluster resources using shared informers
+type InformerResourceProvider struct {
+	ctx           context.Context
+	namespace     string
+	clusterName   string
+	serverVersion string
+	sourceType    string
+	restMapper    meta.RESTMapper
+	// nodeLister and clusterFactory are nil when auditing a namespace, since cluster-scoped resources
+	// aren't watched then
+	nodeLister      corelisters.NodeLister
+	namespaceLister corelisters.NamespaceLister
+	podLister       corelisters.PodLister
+	dynamicFactory  dynamicinformer.DynamicSharedInformerFactory
+	clusterFactory  dynamicinformer.DynamicSharedInformerFactory
+	additionalKinds []schema.GroupVersionKind
+
+	listersLock sync.Mutex
+	listers     map[schema.GroupKind]*listerEntry
+}
+
+// listerEntry is the lister for a kind. once makes sure a single caller starts the informer and waits for it
+// to sync, without holding listersLock, while other callers for the same kind wait for the result.
+type listerEntry struct {
+	once     sync.Once
+	lister   cache.GenericLister
+	err      error
+	failedAt time.Time
+}
+
+// NewInformerResourceProviderFromCluster creates a new InformerResourceProvider using the current kube config
+func NewInformerResourceProviderFromCluster(ctx context.Context, c conf.Configuration) (*InformerResourceProvider, error) {
+	dynamicClient, _, clientSet, clusterHost, err := GetKubeClient(ctx, c.KubeContext)
+	if err != nil {
+		return nil, err
+	}
+	return NewInformerResourceProvider(ctx, clientSet, clusterHost, dynamicClient, c)
+}
+
+// NewInformerResourceProvider starts informers for all the resources an audit needs, and waits for their caches to sync.
+// The informers run until ctx is cancelled.
+func NewInformerResourceProvider(ctx context.Context, kube kubernetes.Interface, clusterName string, dynamic dynamic.Interface, c conf.Configuration) (*InformerResourceProvider, error) {
+	serverVersion, err := kube.Discovery().ServerVersion()
+	if err != nil {
+		logrus.Errorf("Error fetching Cluster API version: %v", err)
+		return nil, err
+	}
+
+	sourceType := "Cluster"
+	if c.Namespace != "" {
+		sourceType = "ClusterNamespace"
+		if _, err := kube.CoreV1().Namespaces().Get(ctx, c.Namespace, metav1.GetOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	resources, err := restmapper.GetAPIGroupResources(kube.Discovery())
+	if err != nil {
+		logrus.Errorf("Error getting API Group resources: %v", err)
+		return nil, err
+	}
+
+	p := &InformerResourceProvider{
+		ctx:            ctx,
+		namespace:      c.Namespace,
+		clusterName:    clusterName,
+		serverVersion:  serverVersion.Major + "." + serverVersion.Minor,
+		sourceType:     sourceType,
+		restMapper:     restmapper.NewDiscoveryRESTMapper(resources),
+		dynamicFactory: dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamic, 0, c.Namespace, nil),
+		listers:        map[schema.GroupKind]*listerEntry{},
+	}
+
+	namespaceInformers := informers.NewSharedInformerFactoryWithOptions(kube, 0, informers.WithNamespace(c.Namespace))
+	p.podLister = namespaceInformers.Core().V1().Pods().Lister()
+	factories := []informers.SharedInformerFactory{namespaceInformers}
+	if c.Namespace == "" {
+		p.clusterFactory = dynamicinformer.NewDynamicSharedInformerFactory(dynamic, 0)
+		clusterInformers := informers.NewSharedInformerFactory(kube, 0)
+		p.nodeLister = clusterInformers.Core().V1().Nodes().Lister()
+		p.namespaceLister = clusterInformers.Core().V1().Namespaces().Lister()
+		factories = append(factories, clusterInformers)
+	} else {
+		// When auditing a namespace, only that Namespace is watched, and no other cluster-scoped resources,
+		// so Polaris only needs permission to read the namespace
+		selectedNamespaceInformers := informers.NewSharedInformerFactoryWithOptions(kube, 0, informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", c.Namespace).String()
+		}))
+		p.namespaceLister = selectedNamespaceInformers.Core().V1().Namespaces().Lister()
+		factories = append(factories, selectedNamespaceInformers)
+	}
+
+	logrus.Info("Starting informers")
+	syncCtx, cancel := context.WithTimeout(ctx, informerSyncTimeout)
+	defer cancel()
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+		for informerType, synced := range factory.WaitForCacheSync(syncCtx.Done()) {
+			if !synced {
+				return nil, fmt.Errorf("timed out waiting for %v informer to sync", informerType)
+			}
+		}
+	}
+
+	for _, kind := range getAdditionalKinds(c) {
+		groupKind := parseGroupKind(maybeTransformKindIntoGroupKind(string(kind)))
+		mapping, err := p.restMapper.RESTMapping(groupKind)
+		if err != nil {
+			logrus.Warnf("error retrieving mapping of Kind %s because of error: %v", kind, err)
+			return nil, err
+		}
+		if c.Namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			logrus.Infof("Skipping %s because of auditing specific namespace", mapping.GroupVersionKind)
+			continue
+		}
+		if _, err := p.getLister(groupKind, mapping.GroupVersionKind.Version); err != nil {
+			return nil, err
+		}
+		p.additionalKinds = append(p.additionalKinds, mapping.GroupVersionKind)
+	}
+	for _, gvk := range topControllerKinds {
+		if _, err := p.getLister(gvk.GroupKind(), gvk.Version); err != nil {
+			logrus.Debugf("Unable to watch objects of kind %s: %v", gvk.Kind, err)
+		}
+	}
+	logrus.Info("Informer caches are synced")
+	return p, nil
+}
+
+// getLister returns a lister for the given kind, starting an informer for it if needed. Failures are
+// remembered for listerRetryInterval, so that a kind that can't be watched doesn't stall every audit.
+func (p *InformerResourceProvider) getLister(groupKind schema.GroupKind, version string) (cache.GenericLister, error) {
+	p.listersLock.Lock()
+	entry, ok := p.listers[groupKind]
+	if !ok || (entry.err != nil && time.Since(entry.failedAt) > listerRetryInterval) {
+		entry = &listerEntry{}
+		p.listers[groupKind] = entry
+	}
+	p.listersLock.Unlock()
+
+	entry.once.Do(func() {
+		lister, err := p.startInformer(groupKind, version)
+		p.listersLock.Lock()
+		defer p.listersLock.Unlock()
+		entry.lister, entry.err = lister, err
+		if err != nil {
+			entry.failedAt = time.Now()
+		}
+	})
+	return entry.lister, entry.err
+}
+
+// startInformer starts an informer for the given kind and waits for its cache to sync
+func (p *InformerResourceProvider) startInformer(groupKind schema.GroupKind, version string) (cache.GenericLister, error) {
+	mapping, err := p.restMapper.RESTMapping(groupKind, version)
+	if err != nil {
+		return nil, err
+	}
+	factory := p.dynamicFactory
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		if p.clusterFactory == nil {
+			return nil, fmt.Errorf("not watching %s, which is cluster-scoped, while auditing namespace %s", mapping.Resource.String(), p.namespace)
+		}
+		factory = p.clusterFactory
+	}
+	informer := factory.ForResource(mapping.Resource)
+	factory.Start(p.ctx.Done())
+	syncCtx, cancel := context.WithTimeout(p.ctx, informerSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.Informer().HasSynced) {
+		return nil, fmt.Errorf("timed out waiting for %s informer to sync", mapping.Resource.String())
+	}
+	return informer.Lister(), nil
+}
+
+// GetResourceProvider returns a ResourceProvider with a copy of the resources currently in the cache
+func (p *InformerResourceProvider) GetResourceProvider() (*ResourceProvider, error) {
+	provider := newResourceProvider(p.serverVersion, p.sourceType, p.clusterName)
+
+	// Nodes aren't watched when auditing a namespace
+	if p.nodeLister != nil {
+		nodes, err := p.nodeLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			provider.Nodes = append(provider.Nodes, *node.DeepCopy())
+		}
+	}
+	namespaces, err := p.namespaceLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range namespaces {
+		if p.namespace == "" || ns.Name == p.namespace {
+			provider.Namespaces = append(provider.Namespaces, *ns.DeepCopy())
+		}
+	}
+	pods, err := p.podLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		provider.Pods = append(provider.Pods, *pod.DeepCopy())
+	}
+	sort.Slice(provider.Nodes, func(i, j int) bool { return provider.Nodes[i].Name < provider.Nodes[j].Name })
+	sort.Slice(provider.Namespaces, func(i, j int) bool { return provider.Namespaces[i].Name < provider.Namespaces[j].Name })
+	sort.Slice(provider.Pods, func(i, j int) bool {
+		return provider.Pods[i].Namespace+"/"+provider.Pods[i].Name < provider.Pods[j].Namespace+"/"+provider.Pods[j].Name
+	})
+
+	var kubernetesResources []GenericResource
+	for _, gvk := range p.additionalKinds {
+		lister, err := p.getLister(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		objects, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range sortObjects(objects) {
+			res, err := NewGenericResourceFromUnstructured(*obj.DeepCopy(), nil)
+			if err != nil {
+				return nil, err
+			}
+			kubernetesResources = append(kubernetesResources, res)
+		}
+	}
+
+	topControllers, err := p.getTopControllers(provider.Pods)
+	if err != nil {
+		return nil, err
+	}
+	for _, topController := range topControllers {
+		workloadObj, err := NewGenericResourceFromUnstructured(topController, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse workload %s/%s: %w", topController.GetNamespace(), topController.GetName(), err)
+		}
+		kubernetesResources = append(kubernetesResources, workloadObj)
+	}
+	provider.Resources.addResources(kubernetesResources)
+	return &provider, nil
+}
+
+// getTopControllers returns the top controllers in the cache, along with the top owner of every pod
+func (p *InformerResourceProvider) getTopControllers(pods []corev1.Pod) ([]unstructured.Unstructured, error) {
+	controllers := map[string]unstructured.Unstructured{}
+	for _, gvk := range topControllerKinds {
+		lister, err := p.getLister(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			continue
+		}
+		objects, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			if u, ok := obj.(*unstructured.Unstructured); ok && len(u.GetOwnerReferences()) == 0 {
+				controllers[getObjectKey(*u)] = *u.DeepCopy()
+			}
+		}
+	}
+	for idx := range pods {
+		podMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pods[idx])
+		if err != nil {
+			return nil, err
+		}
+		pod := unstructured.Unstructured{Object: podMap}
+		pod.SetAPIVersion("v1")
+		pod.SetKind("Pod")
+		controller, err := p.getTopController(pod)
+		if err != nil {
+			// Keep going, so that as many controllers as possible are audited
+			logrus.Warnf("Error retrieving the top controller for pod %s/%s: %v", pod.GetNamespace(), pod.GetName(), err)
+		}
+		controllers[getObjectKey(controller)] = controller
+	}
+
+	keys := make([]string, 0, len(controllers))
+	for key := range controllers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	topControllers := make([]unstructured.Unstructured, 0, len(keys))
+	for _, key := range keys {
+		topControllers = append(topControllers, controllers[key])
+	}
+	return topControllers, nil
+}
+
+// getTopController follows owner references through the cache, returning the object where the walk stopped
+func (p *InformerResourceProvider) getTopController(obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+	owners := obj.GetOwnerReferences()
+	if len(owners) == 0 {
+		return obj, nil
+	}
+	firstOwner := owners[0]
+	if firstOwner.Kind == "Node" {
+		// Static pods are owned by their node, which is not a controller
+		return obj, nil
+	}
+	gv, err := schema.ParseGroupVersion(firstOwner.APIVersion)
+	if err != nil {
+		return obj, err
+	}
+	lister, err := p.getLister(gv.WithKind(firstOwner.Kind).GroupKind(), gv.Version)
+	if err != nil {
+		return obj, err
+	}
+	owner, err := lister.ByNamespace(obj.GetNamespace()).Get(firstOwner.Name)
+	if err != nil {
+		return obj, err
+	}
+	u, ok := owner.(*unstructured.Unstructured)
+	if !ok {
+		return obj, fmt.Errorf("unexpected type %T for %s %s", owner, firstOwner.Kind, firstOwner.Name)
+	}
+	return p.getTopController(*u.DeepCopy())
+}
+
+func getObjectKey(obj unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+func sortObjects(objects []runtime.Object) []*unstructured.Unstructured {
+	sorted := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			sorted = append(sorted, u)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return getObjectKey(*sorted[i]) < getObjectKey(*sorted[j])
+	})
+	return sorted
+}
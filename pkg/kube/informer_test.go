@@ -0,0 +1,172 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestInformerResourceProvider(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	k8s, dynamicInterface := test.SetupTestAPI(test.GetMockControllers("test")...)
+
+	informerProvider, err := NewInformerResourceProvider(ctx, k8s, "test1", dynamicInterface, conf.Configuration{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	resources, err := informerProvider.GetResourceProvider()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Cluster", resources.SourceType)
+	assert.Equal(t, "test1", resources.SourceName)
+	assert.Equal(t, 1, len(resources.Namespaces))
+	assert.Equal(t, 5, len(resources.Pods), "Should have 5 pods")
+	assert.Equal(t, 5, resources.Resources.GetNumberOfControllers(), "Should have 5 controllers")
+
+	// New objects show up without re-listing
+	deploy, _ := test.MockDeploy("test", "new-deploy")
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&deploy)
+	assert.NoError(t, err)
+	deployment := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	_, err = dynamicInterface.Resource(deployment).Namespace("test").Create(ctx, &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		resources, err := informerProvider.GetResourceProvider()
+		return err == nil && resources.Resources.GetNumberOfControllers() == 6
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestInformerResourceProviderNamespace(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	objects := append(test.GetMockControllers("test"), test.GetMockControllers("other")...)
+	k8s, dynamicInterface := test.SetupTestAPI(objects...)
+
+	informerProvider, err := NewInformerResourceProvider(ctx, k8s, "test2", dynamicInterface, conf.Configuration{Namespace: "test"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	resources, err := informerProvider.GetResourceProvider()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ClusterNamespace", resources.SourceType)
+		assert.Equal(t, 1, len(resources.Namespaces))
+		assert.Equal(t, 5, len(resources.Pods), "Should have 5 pods")
+		assert.Equal(t, 5, resources.Resources.GetNumberOfControllers(), "Should have 5 controllers")
+	}
+
+	// Only the audited namespace is watched, and no cluster-scoped resources
+	listedNamespaces := false
+	for _, action := range k8s.(*fake.Clientset).Actions() {
+		if listAction, ok := action.(k8stesting.ListAction); ok {
+			assert.NotEqual(t, "nodes", listAction.GetResource().Resource)
+			if listAction.GetResource().Resource == "namespaces" {
+				listedNamespaces = true
+				assert.Equal(t, "metadata.name=test", listAction.GetListRestrictions().Fields.String())
+			}
+		}
+	}
+	assert.True(t, listedNamespaces)
+
+	_, err = NewInformerResourceProvider(ctx, k8s, "test3", dynamicInterface, conf.Configuration{Namespace: "test3"})
+	assert.Error(t, err)
+}
+
+func getControllerKeys(resources *ResourceProvider) []string {
+	var keys []string
+	for _, controllers := range resources.Resources {
+		for _, controller := range controllers {
+			keys = append(keys, controller.Kind+"/"+controller.ObjectMeta.GetNamespace()+"/"+controller.ObjectMeta.GetName())
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestInformerResourceProviderMatchesAPI(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	objects := append(test.GetMockControllers("test"), test.GetMockControllers("other")...)
+	// A controller without any pods
+	idle, _ := test.MockDeploy("test", "idle")
+	// A pod whose owner can't be looked up, since ReplicaSets aren't served by the test API
+	orphan := test.MockPod()
+	orphan.Name = "orphan"
+	orphan.Namespace = "test"
+	orphan.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "gone"}}
+	objects = append(objects, &idle, &orphan)
+
+	for _, c := range []conf.Configuration{{}, {Namespace: "test"}} {
+		k8s, dynamicInterface := test.SetupTestAPI(objects...)
+		fromAPI, err := CreateResourceProviderFromAPI(ctx, k8s, "test", dynamicInterface, c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		informerProvider, err := NewInformerResourceProvider(ctx, k8s, "test", dynamicInterface, c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		fromInformer, err := informerProvider.GetResourceProvider()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, getControllerKeys(fromAPI), getControllerKeys(fromInformer), "namespace %q", c.Namespace)
+		assert.Contains(t, getControllerKeys(fromInformer), "Deployment/test/idle")
+		assert.Contains(t, getControllerKeys(fromInformer), "Pod/test/orphan")
+	}
+}
+
+func TestInformerResourceProviderListerFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	k8s, dynamicInterface := test.SetupTestAPI(test.GetMockControllers("test")...)
+	informerProvider, err := NewInformerResourceProvider(ctx, k8s, "test", dynamicInterface, conf.Configuration{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// ReplicaSets aren't served by the test API, so the failure is remembered instead of retried on every call
+	replicaSets := schema.GroupKind{Group: "apps", Kind: "ReplicaSet"}
+	_, err = informerProvider.getLister(replicaSets, "v1")
+	assert.Error(t, err)
+	entry := informerProvider.listers[replicaSets]
+	_, err = informerProvider.getLister(replicaSets, "v1")
+	assert.Error(t, err)
+	assert.Same(t, entry, informerProvider.listers[replicaSets])
+
+	// Once the retry interval has passed, the kind is tried again
+	entry.failedAt = time.Now().Add(-2 * listerRetryInterval)
+	_, err = informerProvider.getLister(replicaSets, "v1")
+	assert.Error(t, err)
+	assert.NotSame(t, entry, informerProvider.listers[replicaSets])
+
+	// Other kinds are unaffected
+	_, err = informerProvider.getLister(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "v1")
+	assert.NoError(t, err)
+}
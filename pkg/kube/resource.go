@@ -42,6 +42,14 @@ type GenericResource struct {
 	PodTemplate        any
 	OriginalObjectJSON []byte
 	OriginalObjectYAML []byte
+	// SourceFile is the path of the file the resource was read from, if any
+	SourceFile string
+	// SourceDocumentIndex is the position of the resource's document within SourceFile
+	SourceDocumentIndex int
+	// SourceLine is the line of SourceFile the resource starts on
+	SourceLine int
+	// FieldLines maps JSON pointers into the resource to the line of SourceFile they're declared on
+	FieldLines map[string]int
 }
 
 // NewGenericResourceFromUnstructured creates a workload from an unstructured.Unstructured
@@ -22,7 +22,6 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
@@ -206,7 +205,7 @@ func CreateResourceProviderFromPath(directory string) (*ResourceProvider, error)
 			logrus.Errorf("Error reading file: %v", path)
 			return err
 		}
-		err = resources.addResourcesFromYaml(string(contents))
+		err = resources.addResourcesFromYaml(string(contents), path)
 		if err != nil {
 			logrus.Warnf("skipping %s: cannot add resource from YAML: %v", path, err)
 		}
@@ -223,7 +222,7 @@ func CreateResourceProviderFromPath(directory string) (*ResourceProvider, error)
 // CreateResourceProviderFromYaml returns a new ResourceProvider using the yaml
 func CreateResourceProviderFromYaml(yamlContent string) (*ResourceProvider, error) {
 	resources := newResourceProvider("unknown", "Content", "unknown")
-	err := resources.addResourcesFromYaml(string(yamlContent))
+	err := resources.addResourcesFromYaml(string(yamlContent), "")
 	if err != nil {
 		return nil, err
 	}
@@ -265,6 +264,31 @@ func GetKubeClient(ctx context.Context, kubeContext string) (dynamic.Interface,
 	return dynamicClient, restmapper.NewDiscoveryRESTMapper(resources), clientSet, kubeConf.Host, nil
 }
 
+// getAdditionalKinds returns the kinds that checks need, beyond controllers, nodes, namespaces and pods
+func getAdditionalKinds(c conf.Configuration) []conf.TargetKind {
+	allChecks := []conf.SchemaCheck{}
+	for _, check := range c.CustomChecks {
+		allChecks = append(allChecks, check)
+	}
+	for _, check := range conf.BuiltInChecks {
+		allChecks = append(allChecks, check)
+	}
+
+	var additionalKinds []conf.TargetKind
+	for _, check := range allChecks {
+		neededKinds := []conf.TargetKind{check.Target}
+		for _, key := range check.RelatedKinds() {
+			neededKinds = append(neededKinds, conf.TargetKind(key))
+		}
+		for _, kind := range neededKinds {
+			if !funk.Contains(conf.HandledTargets, kind) && !funk.Contains(additionalKinds, kind) {
+				additionalKinds = append(additionalKinds, kind)
+			}
+		}
+	}
+	return additionalKinds
+}
+
 // CreateResourceProviderFromAPI creates a new ResourceProvider from an existing k8s interface
 func CreateResourceProviderFromAPI(ctx context.Context, kube kubernetes.Interface, clusterName string, dynamic dynamic.Interface, c conf.Configuration) (*ResourceProvider, error) {
 	listOpts := metav1.ListOptions{}
@@ -321,32 +345,9 @@ func CreateResourceProviderFromAPI(ctx context.Context, kube kubernetes.Interfac
 		return nil, err
 	}
 	restMapper := restmapper.NewDiscoveryRESTMapper(resources)
-	allChecks := []conf.SchemaCheck{}
-	for _, check := range c.CustomChecks {
-		allChecks = append(allChecks, check)
-	}
-	for _, check := range conf.BuiltInChecks {
-		allChecks = append(allChecks, check)
-	}
-
-	var additionalKinds []conf.TargetKind
-	for _, check := range allChecks {
-		neededKinds := []conf.TargetKind{check.Target}
-		for key := range check.AdditionalSchemas {
-			neededKinds = append(neededKinds, conf.TargetKind(key))
-		}
-		for key := range check.AdditionalSchemaStrings {
-			neededKinds = append(neededKinds, conf.TargetKind(key))
-		}
-		for _, kind := range neededKinds {
-			if !funk.Contains(conf.HandledTargets, kind) && !funk.Contains(additionalKinds, kind) {
-				additionalKinds = append(additionalKinds, kind)
-			}
-		}
-	}
 
 	var kubernetesResources []GenericResource
-	for _, kind := range additionalKinds {
+	for _, kind := range getAdditionalKinds(c) {
 		groupKind := parseGroupKind(maybeTransformKindIntoGroupKind(string(kind)))
 		mapping, err := restMapper.RESTMapping(groupKind)
 		if err != nil {
@@ -378,7 +379,7 @@ func CreateResourceProviderFromAPI(ctx context.Context, kube kubernetes.Interfac
 		Dynamic:    dynamic,
 		RESTMapper: restMapper,
 	}
-	topControllers, err := client.GetAllTopControllersSummary("")
+	topControllers, err := client.GetAllTopControllersSummary(c.Namespace)
 	if err != nil {
 		return nil, fmt.Errorf("error while getting all TopControllers: %v", err)
 	}
@@ -405,19 +406,15 @@ func (resources *ResourceProvider) addResourcesFromReader(reader io.Reader) erro
 		logrus.Errorf("Error reading from %v: %v", reader, err)
 		return err
 	}
-	if err := resources.addResourcesFromYaml(string(contents)); err != nil {
+	if err := resources.addResourcesFromYaml(string(contents), ""); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (resources *ResourceProvider) addResourcesFromYaml(contents string) error {
-	specs := regexp.MustCompile("[\r\n]-+[\r\n]").Split(string(contents), -1)
-	for _, spec := range specs {
-		if strings.TrimSpace(spec) == "" {
-			continue
-		}
-		err := resources.addResourceFromString(spec)
+func (resources *ResourceProvider) addResourcesFromYaml(contents string, sourceFile string) error {
+	for _, doc := range splitYAMLDocuments(contents, sourceFile) {
+		err := resources.addResourceFromString(doc)
 		if err != nil {
 			logrus.Errorf("Error parsing YAML: (%v)", err)
 			return err
@@ -426,8 +423,8 @@ func (resources *ResourceProvider) addResourcesFromYaml(contents string) error {
 	return nil
 }
 
-func (resources *ResourceProvider) addResourceFromString(contents string) error {
-	contentBytes := []byte(contents)
+func (resources *ResourceProvider) addResourceFromString(doc yamlDocument) error {
+	contentBytes := []byte(doc.Contents)
 	decoder := k8sYaml.NewYAMLOrJSONDecoder(bytes.NewReader(contentBytes), 1000)
 	resource := k8sResource{}
 	err := decoder.Decode(&resource)
@@ -453,6 +450,7 @@ func (resources *ResourceProvider) addResourceFromString(contents string) error
 			return err
 		}
 		workload.OriginalObjectYAML = contentBytes
+		doc.setSource(&workload)
 		resources.Pods = append(resources.Pods, pod)
 		resources.Resources.addResource(workload)
 	} else {
@@ -460,6 +458,7 @@ func (resources *ResourceProvider) addResourceFromString(contents string) error
 		if err != nil {
 			return err
 		}
+		doc.setSource(&newResource)
 		resources.Resources.addResource(newResource)
 	}
 	return err
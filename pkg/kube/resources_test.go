@@ -73,6 +73,34 @@ func TestGetMultipleResourceFromSingleFile(t *testing.T) {
 	assert.Equal(t, "polaris-2", resources.Namespaces[1].ObjectMeta.Name)
 }
 
+func TestResourceSourceLocation(t *testing.T) {
+	resources, err := CreateResourceProviderFromPath("./test_files/test_2/multi.yaml")
+	assert.NoError(t, err)
+
+	deployment := resources.Resources["apps/Deployment"][0]
+	assert.Equal(t, "./test_files/test_2/multi.yaml", deployment.SourceFile)
+	assert.Equal(t, 1, deployment.SourceDocumentIndex)
+	assert.Equal(t, 9, deployment.SourceLine)
+	assert.Equal(t, 14, deployment.GetLineForPath("/metadata/name"))
+	assert.Equal(t, 13, deployment.GetLineForPath("/metadata/annotations/checksum~1config"))
+	assert.Equal(t, 32, deployment.GetLineForPath("/spec/template/spec/containers/0"))
+	assert.Equal(t, 32, deployment.GetLineForPath("/spec/template/spec/containers/0/resources/limits"), "missing fields should fall back to their parent")
+	assert.Equal(t, 9, deployment.GetLineForPath("/"))
+
+	namespaces := resources.Resources["Namespace"]
+	assert.Equal(t, 3, namespaces[0].SourceLine)
+	assert.Equal(t, 48, namespaces[1].SourceLine)
+}
+
+func TestSplitYAMLDocuments(t *testing.T) {
+	docs := splitYAMLDocuments("a: 1\n---\n\n---\r\nb: 2\n-----\nc: 3", "test.yaml")
+	if assert.Len(t, docs, 3) {
+		assert.Equal(t, yamlDocument{Contents: "a: 1\n", SourceFile: "test.yaml", Index: 0, FirstLine: 1}, docs[0])
+		assert.Equal(t, yamlDocument{Contents: "b: 2\n", SourceFile: "test.yaml", Index: 1, FirstLine: 5}, docs[1])
+		assert.Equal(t, yamlDocument{Contents: "c: 3", SourceFile: "test.yaml", Index: 2, FirstLine: 7}, docs[2])
+	}
+}
+
 func TestGetMultipleResourceFromBadFile(t *testing.T) {
 	_, err := CreateResourceProviderFromPath("./test_files/test_3")
 	assert.Equal(t, nil, err, "CreateResource From Path should not fail with bad yaml")
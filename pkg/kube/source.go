@@ -0,0 +1,129 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDocument is a single document of a (possibly multi-document) YAML file,
+// along with where it was found
+type yamlDocument struct {
+	Contents   string
+	SourceFile string
+	// Index is the position of the document within its file, starting at 0
+	Index int
+	// FirstLine is the line of the file the document's contents start on, starting at 1
+	FirstLine int
+}
+
+// splitYAMLDocuments splits YAML contents on lines made up only of dashes,
+// skipping documents that are blank
+func splitYAMLDocuments(contents string, sourceFile string) []yamlDocument {
+	docs := []yamlDocument{}
+	var current strings.Builder
+	firstLine := 1
+	addCurrent := func() {
+		if strings.TrimSpace(current.String()) != "" {
+			docs = append(docs, yamlDocument{
+				Contents:   current.String(),
+				SourceFile: sourceFile,
+				Index:      len(docs),
+				FirstLine:  firstLine,
+			})
+		}
+		current.Reset()
+	}
+	for i, line := range strings.SplitAfter(contents, "\n") {
+		if isYAMLDocumentSeparator(line) {
+			addCurrent()
+			firstLine = i + 2
+			continue
+		}
+		current.WriteString(line)
+	}
+	addCurrent()
+	return docs
+}
+
+func isYAMLDocumentSeparator(line string) bool {
+	line = strings.TrimRight(line, "\r\n")
+	return line != "" && strings.Trim(line, "-") == ""
+}
+
+// setSource records where in its file the resource was declared
+func (doc yamlDocument) setSource(resource *GenericResource) {
+	resource.SourceFile = doc.SourceFile
+	resource.SourceDocumentIndex = doc.Index
+	if doc.SourceFile == "" {
+		// line numbers aren't much use without a file to point at
+		return
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(doc.Contents), &root); err != nil || len(root.Content) == 0 {
+		return
+	}
+	resource.FieldLines = map[string]int{}
+	addFieldLines(resource.FieldLines, root.Content[0], "", doc.FirstLine-1)
+	resource.SourceLine = resource.FieldLines[""]
+}
+
+// addFieldLines maps the JSON pointer of every node under node to the line of the file it is declared on
+func addFieldLines(lines map[string]int, node *yaml.Node, path string, offset int) {
+	if _, ok := lines[path]; !ok {
+		lines[path] = node.Line + offset
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			childPath := path + "/" + escapeJSONPointer(key.Value)
+			lines[childPath] = key.Line + offset
+			addFieldLines(lines, node.Content[i+1], childPath, offset)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			addFieldLines(lines, child, path+"/"+strconv.Itoa(i), offset)
+		}
+	}
+}
+
+func escapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// GetLineForPath returns the line of the source file that declares the field at the given JSON pointer.
+// If the field isn't declared (e.g. a check failed because it is missing), the line of its closest
+// declared parent is returned instead. Zero is returned if the resource wasn't read from a file.
+func (resource GenericResource) GetLineForPath(path string) int {
+	if resource.FieldLines == nil {
+		return 0
+	}
+	path = strings.TrimSuffix(path, "/")
+	for path != "" {
+		if line, ok := resource.FieldLines[path]; ok {
+			return line
+		}
+		lastSlash := strings.LastIndex(path, "/")
+		if lastSlash < 0 {
+			break
+		}
+		path = path[:lastSlash]
+	}
+	return resource.SourceLine
+}
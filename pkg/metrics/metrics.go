@@ -0,0 +1,191 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/validator"
+)
+
+const metricsNamespace = "polaris"
+
+// Result label values for the results gauge
+const (
+	resultSuccess = "success"
+	resultWarning = "warning"
+	resultDanger  = "danger"
+	resultExempt  = "exempted"
+)
+
+// Exporter exposes the results of the latest audit as Prometheus metrics
+type Exporter struct {
+	registry        *prometheus.Registry
+	score           *prometheus.Desc
+	namespaceScore  *prometheus.Desc
+	results         *prometheus.Desc
+	lastAuditTime   *prometheus.Desc
+	auditDuration   *prometheus.Desc
+	auditErrorTotal prometheus.Counter
+
+	// snapshot holds the metrics of the latest audit. It's replaced whole, so a scrape never sees a
+	// partly updated audit. It's nil until the first audit finishes, so no made-up score is exported.
+	lock     sync.RWMutex
+	snapshot *auditSnapshot
+}
+
+// auditSnapshot is the metrics of an audit
+type auditSnapshot struct {
+	score           float64
+	namespaceScores map[string]float64
+	// results are keyed by their label values
+	results       map[resultLabels]float64
+	lastAuditTime float64
+	auditDuration float64
+}
+
+type resultLabels struct {
+	namespace, kind, category, check, result string
+}
+
+// NewExporter creates an Exporter with its own registry
+func NewExporter() *Exporter {
+	e := &Exporter{
+		registry: prometheus.NewRegistry(),
+		score: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "audit_score"),
+			"Overall score of the latest audit, from 0 to 100.",
+			nil, nil),
+		namespaceScore: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "audit_namespace_score"),
+			"Score of the latest audit for each namespace, from 0 to 100.",
+			[]string{"namespace"}, nil),
+		results: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "audit_results"),
+			"Number of check results in the latest audit, by result (success, warning, danger or exempted).",
+			[]string{"namespace", "kind", "category", "check", "result"}, nil),
+		lastAuditTime: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "audit_last_run_timestamp_seconds"),
+			"Unix time the latest successful audit finished.",
+			nil, nil),
+		auditDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "audit_duration_seconds"),
+			"Time taken by the latest successful audit.",
+			nil, nil),
+		auditErrorTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "audit_errors_total",
+			Help:      "Number of audits that failed to run.",
+		}),
+	}
+	e.registry.MustRegister(e, e.auditErrorTotal)
+	return e
+}
+
+// Describe implements prometheus.Collector
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- e.score
+	ch <- e.namespaceScore
+	ch <- e.results
+	ch <- e.lastAuditTime
+	ch <- e.auditDuration
+}
+
+// Collect implements prometheus.Collector, exporting the metrics of the latest audit
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.lock.RLock()
+	snapshot := e.snapshot
+	e.lock.RUnlock()
+	if snapshot == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(e.score, prometheus.GaugeValue, snapshot.score)
+	ch <- prometheus.MustNewConstMetric(e.lastAuditTime, prometheus.GaugeValue, snapshot.lastAuditTime)
+	ch <- prometheus.MustNewConstMetric(e.auditDuration, prometheus.GaugeValue, snapshot.auditDuration)
+	for namespace, score := range snapshot.namespaceScores {
+		ch <- prometheus.MustNewConstMetric(e.namespaceScore, prometheus.GaugeValue, score, namespace)
+	}
+	for labels, count := range snapshot.results {
+		ch <- prometheus.MustNewConstMetric(e.results, prometheus.GaugeValue, count,
+			labels.namespace, labels.kind, labels.category, labels.check, labels.result)
+	}
+}
+
+// Handler serves the metrics
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// RecordError counts an audit that failed to run
+func (e *Exporter) RecordError() {
+	e.auditErrorTotal.Inc()
+}
+
+// Update replaces the exported results with those of a new audit. Resources and checks that have gone
+// away since the last audit are no longer exported.
+func (e *Exporter) Update(auditData validator.AuditData, duration time.Duration) {
+	snapshot := &auditSnapshot{
+		score:           float64(auditData.GetSummary().GetScore()),
+		namespaceScores: map[string]float64{},
+		results:         map[resultLabels]float64{},
+		lastAuditTime:   float64(time.Now().UnixNano()) / 1e9,
+		auditDuration:   duration.Seconds(),
+	}
+	for namespace, results := range auditData.GetResultsByNamespace() {
+		summary := validator.CountSummary{}
+		for _, result := range results {
+			summary.AddSummary(result.GetSummary())
+			snapshot.addResultSet(*result, result.Results)
+			if result.PodResult != nil {
+				snapshot.addResultSet(*result, result.PodResult.Results)
+				for _, containerResult := range result.PodResult.ContainerResults {
+					snapshot.addResultSet(*result, containerResult.Results)
+				}
+			}
+		}
+		snapshot.namespaceScores[namespace] = float64(summary.GetScore())
+	}
+
+	e.lock.Lock()
+	e.snapshot = snapshot
+	e.lock.Unlock()
+}
+
+func (snapshot *auditSnapshot) addResultSet(result validator.Result, resultSet validator.ResultSet) {
+	for checkID, msg := range resultSet {
+		snapshot.results[resultLabels{result.Namespace, result.Kind, msg.Category, checkID, getResultLabel(msg)}]++
+	}
+}
+
+// getResultLabel mirrors how CountSummary.AddResult counts a result
+func getResultLabel(msg validator.ResultMessage) string {
+	if msg.Exempted {
+		return resultExempt
+	}
+	if msg.Success {
+		return resultSuccess
+	}
+	if msg.Severity == config.SeverityWarning {
+		return resultWarning
+	}
+	return resultDanger
+}
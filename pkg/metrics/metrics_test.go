@@ -0,0 +1,150 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/validator"
+)
+
+func getTestAudit() validator.AuditData {
+	return validator.AuditData{
+		Results: []validator.Result{
+			{
+				Name:      "web",
+				Namespace: "default",
+				Kind:      "Deployment",
+				Results: validator.ResultSet{
+					"deploymentMissingReplicas": {ID: "deploymentMissingReplicas", Success: false, Severity: conf.SeverityWarning, Category: "Reliability"},
+				},
+				PodResult: &validator.PodResult{
+					Results: validator.ResultSet{
+						"hostIPCSet": {ID: "hostIPCSet", Success: true, Severity: conf.SeverityDanger, Category: "Security"},
+					},
+					ContainerResults: []validator.ContainerResult{
+						{Name: "nginx", Results: validator.ResultSet{
+							"runAsRootAllowed": {ID: "runAsRootAllowed", Success: false, Severity: conf.SeverityDanger, Category: "Security"},
+						}},
+						{Name: "sidecar", Results: validator.ResultSet{
+							"runAsRootAllowed": {ID: "runAsRootAllowed", Success: false, Severity: conf.SeverityDanger, Category: "Security"},
+						}},
+					},
+				},
+			},
+			{
+				Name:      "db",
+				Namespace: "data",
+				Kind:      "StatefulSet",
+				Results: validator.ResultSet{
+					"hostIPCSet": {ID: "hostIPCSet", Success: true, Severity: conf.SeverityDanger, Category: "Security"},
+				},
+			},
+		},
+	}
+}
+
+func TestExporterUpdate(t *testing.T) {
+	exporter := NewExporter()
+	exporter.Update(getTestAudit(), time.Second)
+
+	expected := `
+# HELP polaris_audit_score Overall score of the latest audit, from 0 to 100.
+# TYPE polaris_audit_score gauge
+polaris_audit_score 44
+# HELP polaris_audit_namespace_score Score of the latest audit for each namespace, from 0 to 100.
+# TYPE polaris_audit_namespace_score gauge
+polaris_audit_namespace_score{namespace="data"} 100
+polaris_audit_namespace_score{namespace="default"} 28
+# HELP polaris_audit_results Number of check results in the latest audit, by result (success, warning, danger or exempted).
+# TYPE polaris_audit_results gauge
+polaris_audit_results{category="Reliability",check="deploymentMissingReplicas",kind="Deployment",namespace="default",result="warning"} 1
+polaris_audit_results{category="Security",check="hostIPCSet",kind="Deployment",namespace="default",result="success"} 1
+polaris_audit_results{category="Security",check="hostIPCSet",kind="StatefulSet",namespace="data",result="success"} 1
+polaris_audit_results{category="Security",check="runAsRootAllowed",kind="Deployment",namespace="default",result="danger"} 2
+# HELP polaris_audit_duration_seconds Time taken by the latest successful audit.
+# TYPE polaris_audit_duration_seconds gauge
+polaris_audit_duration_seconds 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(exporter, strings.NewReader(expected),
+		"polaris_audit_score", "polaris_audit_namespace_score", "polaris_audit_results", "polaris_audit_duration_seconds"))
+
+	// Results that are no longer in the audit should not be exported
+	audit := getTestAudit()
+	audit.Results = audit.Results[1:]
+	exporter.Update(audit, time.Second)
+	expected = `
+# HELP polaris_audit_score Overall score of the latest audit, from 0 to 100.
+# TYPE polaris_audit_score gauge
+polaris_audit_score 100
+# HELP polaris_audit_namespace_score Score of the latest audit for each namespace, from 0 to 100.
+# TYPE polaris_audit_namespace_score gauge
+polaris_audit_namespace_score{namespace="data"} 100
+# HELP polaris_audit_results Number of check results in the latest audit, by result (success, warning, danger or exempted).
+# TYPE polaris_audit_results gauge
+polaris_audit_results{category="Security",check="hostIPCSet",kind="StatefulSet",namespace="data",result="success"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(exporter, strings.NewReader(expected),
+		"polaris_audit_score", "polaris_audit_namespace_score", "polaris_audit_results"))
+}
+
+func TestExporterBeforeFirstAudit(t *testing.T) {
+	exporter := NewExporter()
+	assert.Equal(t, 0, testutil.CollectAndCount(exporter))
+
+	exporter.RecordError()
+	recorder := httptest.NewRecorder()
+	exporter.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	assert.False(t, strings.Contains(body, "polaris_audit_score"))
+	assert.True(t, strings.Contains(body, "polaris_audit_errors_total 1"))
+}
+
+func TestExporterUpdateWhileCollecting(t *testing.T) {
+	exporter := NewExporter()
+	exporter.Update(getTestAudit(), time.Second)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			exporter.Update(getTestAudit(), time.Second)
+		}
+	}()
+	// Each scrape sees every series of an audit: 3 gauges, 2 namespace scores and 4 results
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, 9, testutil.CollectAndCount(exporter))
+	}
+	<-done
+}
+
+func TestExporterHandler(t *testing.T) {
+	exporter := NewExporter()
+	exporter.Update(getTestAudit(), time.Second)
+	exporter.RecordError()
+
+	recorder := httptest.NewRecorder()
+	exporter.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	assert.True(t, strings.Contains(body, "polaris_audit_score 44"))
+	assert.True(t, strings.Contains(body, `polaris_audit_results{category="Security",check="hostIPCSet",kind="StatefulSet",namespace="data",result="success"} 1`))
+	assert.True(t, strings.Contains(body, "polaris_audit_errors_total 1"))
+}
@@ -0,0 +1,185 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// BaselineVersion is the version of the current baseline file structure
+const BaselineVersion = "1.0"
+
+// Baseline is a set of known failures that should not be reported
+type Baseline struct {
+	BaselineVersion string
+	Entries         []BaselineEntry
+}
+
+// BaselineEntry identifies a single failed check on a single resource or container
+type BaselineEntry struct {
+	Fingerprint string
+	Check       string
+	Kind        string
+	Namespace   string `json:",omitempty"`
+	Name        string
+	Container   string `json:",omitempty"`
+}
+
+// String returns a human-readable description of the entry
+func (e BaselineEntry) String() string {
+	nameParts := []string{e.Kind}
+	if e.Namespace != "" {
+		nameParts = append(nameParts, e.Namespace)
+	}
+	nameParts = append(nameParts, e.Name)
+	str := fmt.Sprintf("%s on %s", e.Check, strings.Join(nameParts, "/"))
+	if e.Container != "" {
+		str += fmt.Sprintf(" (container %s)", e.Container)
+	}
+	return str
+}
+
+func newBaselineEntry(checkID string, result Result, containerName string) BaselineEntry {
+	entry := BaselineEntry{
+		Check:     checkID,
+		Kind:      result.Kind,
+		Namespace: result.Namespace,
+		Name:      result.Name,
+		Container: containerName,
+	}
+	hash := sha256.Sum256([]byte(strings.Join([]string{entry.Check, entry.Kind, entry.Namespace, entry.Name, entry.Container}, "/")))
+	entry.Fingerprint = hex.EncodeToString(hash[:])
+	return entry
+}
+
+// ReadBaselineFromFile reads a baseline previously written by WriteToFile
+func ReadBaselineFromFile(fileName string) (Baseline, error) {
+	baseline := Baseline{}
+	contents, err := os.ReadFile(fileName)
+	if err != nil {
+		return baseline, err
+	}
+	if err := json.Unmarshal(contents, &baseline); err != nil {
+		return baseline, fmt.Errorf("Decoding baseline %s failed: %v", fileName, err)
+	}
+	return baseline, nil
+}
+
+// WriteToFile saves the baseline as JSON
+func (b Baseline) WriteToFile(fileName string) error {
+	contents, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, append(contents, '\n'), 0644)
+}
+
+// GetBaseline returns a baseline containing every failed check in the audit
+func (res AuditData) GetBaseline() Baseline {
+	baseline := Baseline{
+		BaselineVersion: BaselineVersion,
+		Entries:         []BaselineEntry{},
+	}
+	for _, result := range res.Results {
+		result.forEachResultSet(func(containerName string, resultSet ResultSet) {
+			for checkID, msg := range resultSet {
+				if !msg.Success {
+					baseline.Entries = append(baseline.Entries, newBaselineEntry(checkID, result, containerName))
+				}
+			}
+		})
+	}
+	sort.Slice(baseline.Entries, func(i, j int) bool {
+		return baseline.Entries[i].Fingerprint < baseline.Entries[j].Fingerprint
+	})
+	return baseline
+}
+
+// ApplyBaseline removes failures that are recorded in the baseline and recalculates the score.
+// Baseline entries that no longer fail are returned in FixedBaselineEntries.
+func (res AuditData) ApplyBaseline(baseline Baseline) AuditData {
+	known := map[string]BaselineEntry{}
+	for _, entry := range baseline.Entries {
+		known[entry.Fingerprint] = entry
+	}
+	stillFailing := map[string]bool{}
+
+	resCopy := res
+	resCopy.Results = []Result{}
+	for _, result := range res.Results {
+		resultCopy := result.copyResultSets(func(containerName string, resultSet ResultSet) ResultSet {
+			newResults := ResultSet{}
+			for checkID, msg := range resultSet {
+				if !msg.Success {
+					fingerprint := newBaselineEntry(checkID, result, containerName).Fingerprint
+					if _, ok := known[fingerprint]; ok {
+						stillFailing[fingerprint] = true
+						continue
+					}
+				}
+				newResults[checkID] = msg
+			}
+			return newResults
+		})
+		resCopy.Results = append(resCopy.Results, resultCopy)
+	}
+
+	resCopy.FixedBaselineEntries = []BaselineEntry{}
+	for _, entry := range baseline.Entries {
+		if !stillFailing[entry.Fingerprint] {
+			resCopy.FixedBaselineEntries = append(resCopy.FixedBaselineEntries, entry)
+		}
+	}
+	resCopy.Score = resCopy.GetSummary().GetScore()
+	return resCopy
+}
+
+// forEachResultSet calls fn with the controller, pod and container result sets of a Result.
+// containerName is empty for everything but container results.
+func (res Result) forEachResultSet(fn func(containerName string, resultSet ResultSet)) {
+	fn("", res.Results)
+	if res.PodResult == nil {
+		return
+	}
+	fn("", res.PodResult.Results)
+	for _, containerResult := range res.PodResult.ContainerResults {
+		fn(containerResult.Name, containerResult.Results)
+	}
+}
+
+// copyResultSets returns a copy of the Result with every result set replaced by the output of fn
+func (res Result) copyResultSets(fn func(containerName string, resultSet ResultSet) ResultSet) Result {
+	resCopy := res
+	resCopy.Results = fn("", res.Results)
+	if res.PodResult != nil {
+		podCopy := *res.PodResult
+		podCopy.Results = fn("", res.PodResult.Results)
+		podCopy.ContainerResults = make([]ContainerResult, len(res.PodResult.ContainerResults))
+		for i, containerResult := range res.PodResult.ContainerResults {
+			podCopy.ContainerResults[i] = ContainerResult{
+				Name:    containerResult.Name,
+				Results: fn(containerResult.Name, containerResult.Results),
+			}
+		}
+		resCopy.PodResult = &podCopy
+	}
+	return resCopy
+}
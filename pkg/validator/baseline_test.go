@@ -0,0 +1,95 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"path/filepath"
+	"testing"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func getBaselineTestAudit() AuditData {
+	audit := AuditData{
+		Results: []Result{
+			{
+				Name:      "web",
+				Namespace: "default",
+				Kind:      "Deployment",
+				Results: ResultSet{
+					"deploymentMissingReplicas": {ID: "deploymentMissingReplicas", Success: false, Severity: conf.SeverityWarning},
+				},
+				PodResult: &PodResult{
+					Results: ResultSet{
+						"hostIPCSet": {ID: "hostIPCSet", Success: true, Severity: conf.SeverityDanger},
+					},
+					ContainerResults: []ContainerResult{
+						{
+							Name: "nginx",
+							Results: ResultSet{
+								"runAsRootAllowed": {ID: "runAsRootAllowed", Success: false, Severity: conf.SeverityDanger},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	audit.Score = audit.GetSummary().GetScore()
+	return audit
+}
+
+func TestBaseline(t *testing.T) {
+	audit := getBaselineTestAudit()
+	baseline := audit.GetBaseline()
+	assert.Equal(t, BaselineVersion, baseline.BaselineVersion)
+	if !assert.Len(t, baseline.Entries, 2) {
+		return
+	}
+	for _, entry := range baseline.Entries {
+		assert.Len(t, entry.Fingerprint, 64)
+		if entry.Check == "runAsRootAllowed" {
+			assert.Equal(t, "nginx", entry.Container)
+			assert.Equal(t, "runAsRootAllowed on Deployment/default/web (container nginx)", entry.String())
+		}
+	}
+
+	fileName := filepath.Join(t.TempDir(), "baseline.json")
+	assert.NoError(t, baseline.WriteToFile(fileName))
+	readBaseline, err := ReadBaselineFromFile(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, baseline, readBaseline)
+
+	filtered := audit.ApplyBaseline(baseline)
+	assert.Equal(t, CountSummary{Successes: 1}, filtered.GetSummary())
+	assert.Equal(t, uint(100), filtered.Score)
+	assert.Empty(t, filtered.FixedBaselineEntries)
+	assert.Len(t, audit.Results[0].Results, 1, "the original audit should not be modified")
+}
+
+func TestBaselineNewAndFixedFailures(t *testing.T) {
+	baseline := getBaselineTestAudit().GetBaseline()
+
+	audit := getBaselineTestAudit()
+	audit.Results[0].Results = ResultSet{}
+	audit.Results[0].PodResult.Results["hostIPCSet"] = ResultMessage{ID: "hostIPCSet", Success: false, Severity: conf.SeverityDanger}
+
+	filtered := audit.ApplyBaseline(baseline)
+	assert.Equal(t, CountSummary{Dangers: 1}, filtered.GetSummary())
+	if assert.Len(t, filtered.FixedBaselineEntries, 1) {
+		assert.Equal(t, "deploymentMissingReplicas", filtered.FixedBaselineEntries[0].Check)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/kube"
+	"github.com/fairwindsops/polaris/test"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func getBenchmarkResources(tb testing.TB, namespaces int) *kube.ResourceProvider {
+	var objects []k8sruntime.Object
+	for i := 0; i < namespaces; i++ {
+		objects = append(objects, test.GetMockControllers(fmt.Sprintf("ns-%d", i))...)
+	}
+	k8s, dynamicClient := test.SetupTestAPI(objects...)
+	resources, err := kube.CreateResourceProviderFromAPI(context.Background(), k8s, "test", dynamicClient, conf.Configuration{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return resources
+}
+
+func TestParallelAuditIsDeterministic(t *testing.T) {
+	c, err := conf.MergeConfigAndParseFile("", false)
+	assert.NoError(t, err)
+	resources := getBenchmarkResources(t, 4)
+
+	c.Parallelism = 1
+	sequential, err := RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	c.Parallelism = 8
+	parallel, err := RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	assert.Equal(t, sequential.Results, parallel.Results)
+}
+
+func BenchmarkRunAudit(b *testing.B) {
+	logrus.SetLevel(logrus.ErrorLevel)
+	c, err := conf.MergeConfigAndParseFile("", false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	resources := getBenchmarkResources(b, 20)
+	for _, parallelism := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			c.Parallelism = parallelism
+			for i := 0; i < b.N; i++ {
+				if _, err := RunAudit(context.Background(), c, resources); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
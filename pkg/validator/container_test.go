@@ -112,6 +112,11 @@ func TestValidateResourcesEmptyContainer(t *testing.T) {
 			Success:  false,
 			Severity: "warning",
 			Message:  "CPU requests should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"requests" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 		{
@@ -119,6 +124,11 @@ func TestValidateResourcesEmptyContainer(t *testing.T) {
 			Success:  false,
 			Severity: "warning",
 			Message:  "Memory requests should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"requests" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 	}
@@ -129,6 +139,11 @@ func TestValidateResourcesEmptyContainer(t *testing.T) {
 			Success:  false,
 			Severity: "danger",
 			Message:  "CPU limits should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"limits" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 		{
@@ -136,6 +151,11 @@ func TestValidateResourcesEmptyContainer(t *testing.T) {
 			Success:  false,
 			Severity: "danger",
 			Message:  "Memory limits should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"limits" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 	}
@@ -166,8 +186,17 @@ func TestValidateHealthChecks(t *testing.T) {
 		ReadinessProbe: &probe,
 	}
 
-	l := ResultMessage{ID: "livenessProbeMissing", Success: false, Severity: "warning", Message: "Liveness probe should be configured", Category: "Reliability"}
-	r := ResultMessage{ID: "readinessProbeMissing", Success: false, Severity: "danger", Message: "Readiness probe should be configured", Category: "Reliability"}
+	emptyContainerValue := map[string]any{"name": "", "resources": map[string]any{}}
+	l := ResultMessage{ID: "livenessProbeMissing", Success: false, Severity: "warning", Message: "Liveness probe should be configured", Category: "Reliability", Details: []ResultDetail{{
+		PropertyPath: "/spec/containers/-1",
+		InvalidValue: emptyContainerValue,
+		Message:      `"livenessProbe" value is required`,
+	}}}
+	r := ResultMessage{ID: "readinessProbeMissing", Success: false, Severity: "danger", Message: "Readiness probe should be configured", Category: "Reliability", Details: []ResultDetail{{
+		PropertyPath: "/spec/containers/-1",
+		InvalidValue: emptyContainerValue,
+		Message:      `"readinessProbe" value is required`,
+	}}}
 	f1 := []ResultMessage{}
 	f2 := []ResultMessage{r}
 	w1 := []ResultMessage{l}
@@ -245,8 +274,13 @@ func TestValidateImage(t *testing.T) {
 			image:     standardConf,
 			container: emptyContainer,
 			expected: []ResultMessage{{
-				ID:       "tagNotSpecified",
-				Message:  "Image tag should be specified",
+				ID:      "tagNotSpecified",
+				Message: "Image tag should be specified",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1",
+					InvalidValue: map[string]any{"name": "", "resources": map[string]any{}},
+					Message:      `"image" value is required`,
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Reliability",
@@ -257,8 +291,13 @@ func TestValidateImage(t *testing.T) {
 			image:     standardConf,
 			container: badContainer,
 			expected: []ResultMessage{{
-				ID:       "tagNotSpecified",
-				Message:  "Image tag should be specified",
+				ID:      "tagNotSpecified",
+				Message: "Image tag should be specified",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/image",
+					InvalidValue: "test",
+					Message:      "regexp pattern ^.+:.+$ mismatch on string: test",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Reliability",
@@ -269,8 +308,13 @@ func TestValidateImage(t *testing.T) {
 			image:     standardConf,
 			container: lessBadContainer,
 			expected: []ResultMessage{{
-				ID:       "tagNotSpecified",
-				Message:  "Image tag should be specified",
+				ID:      "tagNotSpecified",
+				Message: "Image tag should be specified",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/image",
+					InvalidValue: "test:latest",
+					Message:      "result was valid, ('not') expected invalid",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Reliability",
@@ -281,14 +325,24 @@ func TestValidateImage(t *testing.T) {
 			image:     strongConf,
 			container: badContainer,
 			expected: []ResultMessage{{
-				ID:       "pullPolicyNotAlways",
-				Message:  "Image pull policy should be \"Always\"",
+				ID:      "pullPolicyNotAlways",
+				Message: "Image pull policy should be \"Always\"",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1",
+					InvalidValue: map[string]any{"image": "test", "name": "", "resources": map[string]any{}},
+					Message:      `"imagePullPolicy" value is required`,
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Reliability",
 			}, {
-				ID:       "tagNotSpecified",
-				Message:  "Image tag should be specified",
+				ID:      "tagNotSpecified",
+				Message: "Image tag should be specified",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/image",
+					InvalidValue: "test",
+					Message:      "regexp pattern ^.+:.+$ mismatch on string: test",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Reliability",
@@ -386,8 +440,13 @@ func TestValidateNetworking(t *testing.T) {
 			networkConf: standardConf,
 			container:   badContainer,
 			expectedResults: []ResultMessage{{
-				ID:       "hostPortSet",
-				Message:  "Host port should not be configured",
+				ID:      "hostPortSet",
+				Message: "Host port should not be configured",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/ports/0/hostPort",
+					InvalidValue: float64(443),
+					Message:      "must equal 0",
+				}},
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -410,8 +469,13 @@ func TestValidateNetworking(t *testing.T) {
 			networkConf: strongConf,
 			container:   badContainer,
 			expectedResults: []ResultMessage{{
-				ID:       "hostPortSet",
-				Message:  "Host port should not be configured",
+				ID:      "hostPortSet",
+				Message: "Host port should not be configured",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/ports/0/hostPort",
+					InvalidValue: float64(443),
+					Message:      "must equal 0",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
@@ -520,6 +584,24 @@ func TestValidateSecurity(t *testing.T) {
 		},
 	}
 
+	emptyContainerValue := map[string]any{"name": "", "resources": map[string]any{}}
+	addedCapabilities := []any{"AUDIT_WRITE", "SYS_ADMIN", "NET_ADMIN"}
+	badContainerValue := map[string]any{"name": "", "resources": map[string]any{}, "securityContext": map[string]any{
+		"allowPrivilegeEscalation": true,
+		"capabilities":             map[string]any{"add": addedCapabilities},
+		"privileged":               true,
+		"readOnlyRootFilesystem":   false,
+		"runAsNonRoot":             false,
+	}}
+	// Checks that look at the pod's security context fail on the pod spec, validated with only the container under test
+	podSpecDetails := func(container map[string]any, podSecurityContext map[string]any) []ResultDetail {
+		spec := map[string]any{"containers": []any{container}}
+		if podSecurityContext != nil {
+			spec["securityContext"] = podSecurityContext
+		}
+		return []ResultDetail{{PropertyPath: "/spec", InvalidValue: spec, Message: "did Not match any specified AnyOf schemas"}}
+	}
+
 	var testCases = []struct {
 		name            string
 		securityConf    map[string]conf.Severity
@@ -542,12 +624,14 @@ func TestValidateSecurity(t *testing.T) {
 			expectedResults: []ResultMessage{{
 				ID:       "runAsRootAllowed",
 				Message:  "Should not be allowed to run as root",
+				Details:  podSpecDetails(emptyContainerValue, nil),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
 			}, {
 				ID:       "notReadOnlyRootFilesystem",
 				Message:  "Filesystem should be read only",
+				Details:  podSpecDetails(emptyContainerValue, nil),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -560,12 +644,18 @@ func TestValidateSecurity(t *testing.T) {
 			}, {
 				ID:       "privilegeEscalationAllowed",
 				Message:  "Privilege escalation should not be allowed",
+				Details:  podSpecDetails(emptyContainerValue, nil),
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
-				ID:       "insecureCapabilities",
-				Message:  "Container should not have insecure capabilities",
+				ID:      "insecureCapabilities",
+				Message: "Container should not have insecure capabilities",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1",
+					InvalidValue: emptyContainerValue,
+					Message:      `"securityContext" value is required`,
+				}},
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -583,38 +673,60 @@ func TestValidateSecurity(t *testing.T) {
 			container:    badContainer,
 			pod:          emptyPodSpec,
 			expectedResults: []ResultMessage{{
-				ID:       "dangerousCapabilities",
-				Message:  "Container should not have dangerous capabilities",
+				ID:      "dangerousCapabilities",
+				Message: "Container should not have dangerous capabilities",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities/add",
+					InvalidValue: addedCapabilities,
+					Message:      "result was valid, ('not') expected invalid",
+				}, {
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities/add",
+					InvalidValue: addedCapabilities,
+					Message:      "result was valid, ('not') expected invalid",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
 				ID:       "privilegeEscalationAllowed",
 				Message:  "Privilege escalation should not be allowed",
+				Details:  podSpecDetails(badContainerValue, nil),
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
-				ID:       "runAsPrivileged",
-				Message:  "Should not be running as privileged",
+				ID:      "runAsPrivileged",
+				Message: "Should not be running as privileged",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/privileged",
+					InvalidValue: true,
+					Message:      "result was valid, ('not') expected invalid",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
-				ID:       "insecureCapabilities",
-				Message:  "Container should not have insecure capabilities",
+				ID:      "insecureCapabilities",
+				Message: "Container should not have insecure capabilities",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities",
+					InvalidValue: map[string]any{"add": addedCapabilities},
+					Message:      `"drop" value is required`,
+				}},
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
 			}, {
 				ID:       "runAsRootAllowed",
 				Message:  "Should not be allowed to run as root",
+				Details:  podSpecDetails(badContainerValue, nil),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
 			}, {
 				ID:       "notReadOnlyRootFilesystem",
 				Message:  "Filesystem should be read only",
+				Details:  podSpecDetails(badContainerValue, nil),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -626,38 +738,60 @@ func TestValidateSecurity(t *testing.T) {
 			container:    badContainer,
 			pod:          goodPodSpec,
 			expectedResults: []ResultMessage{{
-				ID:       "dangerousCapabilities",
-				Message:  "Container should not have dangerous capabilities",
+				ID:      "dangerousCapabilities",
+				Message: "Container should not have dangerous capabilities",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities/add",
+					InvalidValue: addedCapabilities,
+					Message:      "result was valid, ('not') expected invalid",
+				}, {
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities/add",
+					InvalidValue: addedCapabilities,
+					Message:      "result was valid, ('not') expected invalid",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
 				ID:       "privilegeEscalationAllowed",
 				Message:  "Privilege escalation should not be allowed",
+				Details:  podSpecDetails(badContainerValue, map[string]any{"runAsNonRoot": true}),
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
-				ID:       "runAsPrivileged",
-				Message:  "Should not be running as privileged",
+				ID:      "runAsPrivileged",
+				Message: "Should not be running as privileged",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/privileged",
+					InvalidValue: true,
+					Message:      "result was valid, ('not') expected invalid",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
-				ID:       "insecureCapabilities",
-				Message:  "Container should not have insecure capabilities",
+				ID:      "insecureCapabilities",
+				Message: "Container should not have insecure capabilities",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities",
+					InvalidValue: map[string]any{"add": addedCapabilities},
+					Message:      `"drop" value is required`,
+				}},
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
 			}, {
 				ID:       "runAsRootAllowed",
 				Message:  "Should not be allowed to run as root",
+				Details:  podSpecDetails(badContainerValue, map[string]any{"runAsNonRoot": true}),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
 			}, {
 				ID:       "notReadOnlyRootFilesystem",
 				Message:  "Filesystem should be read only",
+				Details:  podSpecDetails(badContainerValue, map[string]any{"runAsNonRoot": true}),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -669,38 +803,60 @@ func TestValidateSecurity(t *testing.T) {
 			container:    badContainer,
 			pod:          badPodSpec,
 			expectedResults: []ResultMessage{{
-				ID:       "dangerousCapabilities",
-				Message:  "Container should not have dangerous capabilities",
+				ID:      "dangerousCapabilities",
+				Message: "Container should not have dangerous capabilities",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities/add",
+					InvalidValue: addedCapabilities,
+					Message:      "result was valid, ('not') expected invalid",
+				}, {
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities/add",
+					InvalidValue: addedCapabilities,
+					Message:      "result was valid, ('not') expected invalid",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
-				ID:       "insecureCapabilities",
-				Message:  "Container should not have insecure capabilities",
+				ID:      "insecureCapabilities",
+				Message: "Container should not have insecure capabilities",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities",
+					InvalidValue: map[string]any{"add": addedCapabilities},
+					Message:      `"drop" value is required`,
+				}},
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
 			}, {
 				ID:       "privilegeEscalationAllowed",
 				Message:  "Privilege escalation should not be allowed",
+				Details:  podSpecDetails(badContainerValue, map[string]any{"runAsNonRoot": false}),
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
-				ID:       "runAsPrivileged",
-				Message:  "Should not be running as privileged",
+				ID:      "runAsPrivileged",
+				Message: "Should not be running as privileged",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/privileged",
+					InvalidValue: true,
+					Message:      "result was valid, ('not') expected invalid",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
 			}, {
 				ID:       "runAsRootAllowed",
 				Message:  "Should not be allowed to run as root",
+				Details:  podSpecDetails(badContainerValue, map[string]any{"runAsNonRoot": false}),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
 			}, {
 				ID:       "notReadOnlyRootFilesystem",
 				Message:  "Filesystem should be read only",
+				Details:  podSpecDetails(badContainerValue, map[string]any{"runAsNonRoot": false}),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -742,8 +898,13 @@ func TestValidateSecurity(t *testing.T) {
 				Severity: "danger",
 				Category: "Security",
 			}, {
-				ID:       "insecureCapabilities",
-				Message:  "Container should not have insecure capabilities",
+				ID:      "insecureCapabilities",
+				Message: "Container should not have insecure capabilities",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities/drop",
+					InvalidValue: []any{"NET_BIND_SERVICE", "FOWNER"},
+					Message:      "did not match any of the specified OneOf schemas",
+				}},
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -761,8 +922,13 @@ func TestValidateSecurity(t *testing.T) {
 				Severity: "danger",
 				Category: "Security",
 			}, {
-				ID:       "insecureCapabilities",
-				Message:  "Container should not have insecure capabilities",
+				ID:      "insecureCapabilities",
+				Message: "Container should not have insecure capabilities",
+				Details: []ResultDetail{{
+					PropertyPath: "/spec/containers/-1/securityContext/capabilities/drop",
+					InvalidValue: []any{"NET_BIND_SERVICE", "FOWNER"},
+					Message:      "did not match any of the specified OneOf schemas",
+				}},
 				Success:  false,
 				Severity: "danger",
 				Category: "Security",
@@ -988,6 +1154,17 @@ func TestValidateRunAsRoot(t *testing.T) {
 		},
 	}
 	emptyPod := &corev1.PodSpec{}
+	// The check fails on the pod spec, validated with only the container under test
+	podSpecDetails := func(containerSecurityContext, podSecurityContext map[string]any) []ResultDetail {
+		return []ResultDetail{{
+			PropertyPath: "/spec",
+			InvalidValue: map[string]any{
+				"containers":      []any{map[string]any{"name": "", "resources": map[string]any{}, "securityContext": containerSecurityContext}},
+				"securityContext": podSecurityContext,
+			},
+			Message: "did Not match any specified AnyOf schemas",
+		}}
+	}
 
 	testCases := []struct {
 		name      string
@@ -1002,6 +1179,7 @@ func TestValidateRunAsRoot(t *testing.T) {
 			message: ResultMessage{
 				ID:       "runAsRootAllowed",
 				Message:  "Should not be allowed to run as root",
+				Details:  podSpecDetails(map[string]any{}, map[string]any{"runAsNonRoot": false}),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -1050,6 +1228,7 @@ func TestValidateRunAsRoot(t *testing.T) {
 			message: ResultMessage{
 				ID:       "runAsRootAllowed",
 				Message:  "Should not be allowed to run as root",
+				Details:  podSpecDetails(map[string]any{"runAsUser": float64(0)}, map[string]any{"runAsUser": float64(1000)}),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -1062,6 +1241,7 @@ func TestValidateRunAsRoot(t *testing.T) {
 			message: ResultMessage{
 				ID:       "runAsRootAllowed",
 				Message:  "Should not be allowed to run as root",
+				Details:  podSpecDetails(map[string]any{"runAsNonRoot": false}, map[string]any{"runAsUser": float64(1000)}),
 				Success:  false,
 				Severity: "warning",
 				Category: "Security",
@@ -1105,6 +1285,11 @@ func TestValidateResourcesExemption(t *testing.T) {
 			Success:  false,
 			Severity: "warning",
 			Message:  "CPU requests should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"requests" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 		{
@@ -1112,6 +1297,11 @@ func TestValidateResourcesExemption(t *testing.T) {
 			Success:  false,
 			Severity: "warning",
 			Message:  "Memory requests should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"requests" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 	}
@@ -1122,6 +1312,11 @@ func TestValidateResourcesExemption(t *testing.T) {
 			Success:  false,
 			Severity: "danger",
 			Message:  "CPU limits should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"limits" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 		{
@@ -1129,6 +1324,11 @@ func TestValidateResourcesExemption(t *testing.T) {
 			Success:  false,
 			Severity: "danger",
 			Message:  "Memory limits should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"limits" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 	}
@@ -1149,6 +1349,11 @@ func TestValidateResourcesEmptyContainerCPURequestsExempt(t *testing.T) {
 			Success:  false,
 			Severity: "warning",
 			Message:  "Memory requests should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"requests" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 	}
@@ -1159,6 +1364,11 @@ func TestValidateResourcesEmptyContainerCPURequestsExempt(t *testing.T) {
 			Success:  false,
 			Severity: "danger",
 			Message:  "CPU limits should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"limits" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 		{
@@ -1166,6 +1376,11 @@ func TestValidateResourcesEmptyContainerCPURequestsExempt(t *testing.T) {
 			Success:  false,
 			Severity: "danger",
 			Message:  "Memory limits should be set",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources",
+				InvalidValue: map[string]any{},
+				Message:      `"limits" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 	}
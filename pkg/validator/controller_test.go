@@ -132,9 +132,18 @@ func TestSkipHealthChecks(t *testing.T) {
 		Warnings:  uint(1),
 		Dangers:   uint(1),
 	}
+	containerValue := map[string]any{"name": "test", "resources": map[string]any{}}
 	expectedResults := ResultSet{
-		"readinessProbeMissing": {ID: "readinessProbeMissing", Message: "Readiness probe should be configured", Success: false, Severity: "danger", Category: "Reliability"},
-		"livenessProbeMissing":  {ID: "livenessProbeMissing", Message: "Liveness probe should be configured", Success: false, Severity: "warning", Category: "Reliability"},
+		"readinessProbeMissing": {ID: "readinessProbeMissing", Message: "Readiness probe should be configured", Success: false, Severity: "danger", Category: "Reliability", Details: []ResultDetail{{
+			PropertyPath: "/spec/template/spec/containers/0",
+			InvalidValue: containerValue,
+			Message:      `"readinessProbe" value is required`,
+		}}},
+		"livenessProbeMissing": {ID: "livenessProbeMissing", Message: "Liveness probe should be configured", Success: false, Severity: "warning", Category: "Reliability", Details: []ResultDetail{{
+			PropertyPath: "/spec/template/spec/containers/0",
+			InvalidValue: containerValue,
+			Message:      `"livenessProbe" value is required`,
+		}}},
 	}
 	var actualResult Result
 	actualResult, err = applyControllerSchemaChecks(context.Background(), &c, nil, deployment)
@@ -250,3 +259,78 @@ func TestControllerExemptions(t *testing.T) {
 	assert.Equal(t, "Deployment", actualResults[0].Kind)
 	assert.EqualValues(t, expectedSum, actualResults[0].GetSummary())
 }
+
+func TestControllerIncludeExempted(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"readinessProbeMissing": conf.SeverityDanger,
+			"livenessProbeMissing":  conf.SeverityWarning,
+		},
+		Exemptions: []conf.Exemption{{
+			Rules:  []string{"readinessProbeMissing"},
+			Reason: "serves no traffic",
+		}},
+		IncludeExempted: true,
+	}
+
+	pod := test.MockPod()
+	pod.ObjectMeta.Annotations = map[string]string{
+		"polaris.fairwinds.com/livenessProbeMissing-exempt": "true",
+	}
+	workload, err := kube.NewGenericResourceFromPod(pod, nil)
+	assert.NoError(t, err)
+	workload.Kind = "Deployment"
+
+	actualResult, err := ApplyAllSchemaChecks(context.Background(), &c, nil, workload)
+	assert.NoError(t, err)
+	assert.EqualValues(t, CountSummary{Exempted: 2}, actualResult.GetSummary())
+	assert.Equal(t, uint(100), actualResult.GetSummary().GetScore())
+
+	results := actualResult.PodResult.ContainerResults[0].Results
+	assert.Equal(t, &ResultExemption{
+		Source: ExemptionSourceConfig,
+		Reason: "serves no traffic",
+	}, results["readinessProbeMissing"].Exemption)
+	assert.Equal(t, &ResultExemption{
+		Source:     ExemptionSourceAnnotation,
+		Annotation: "polaris.fairwinds.com/livenessProbeMissing-exempt",
+	}, results["livenessProbeMissing"].Exemption)
+	assert.True(t, results["livenessProbeMissing"].Exempted)
+	assert.Equal(t, "exempted by config exemption 0: serves no traffic", results["readinessProbeMissing"].Exemption.String())
+
+	c.IncludeExempted = false
+	actualResult, err = ApplyAllSchemaChecks(context.Background(), &c, nil, workload)
+	assert.NoError(t, err)
+	assert.EqualValues(t, CountSummary{}, actualResult.GetSummary())
+}
+
+func TestControllerSeverityOverrides(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"readinessProbeMissing": conf.SeverityWarning,
+			"livenessProbeMissing":  conf.SeverityIgnore,
+		},
+		SeverityOverrides: []conf.SeverityOverride{{
+			Namespaces: []string{"prod-*"},
+			Checks: map[string]conf.Severity{
+				"readinessProbeMissing": conf.SeverityDanger,
+				"livenessProbeMissing":  conf.SeverityWarning,
+			},
+		}},
+	}
+
+	pod := test.MockPod()
+	pod.ObjectMeta.Namespace = "dev"
+	workload, err := kube.NewGenericResourceFromPod(pod, nil)
+	assert.NoError(t, err)
+	workload.Kind = "Deployment"
+	actualResult, err := ApplyAllSchemaChecks(context.Background(), &c, nil, workload)
+	assert.NoError(t, err)
+	assert.EqualValues(t, CountSummary{Warnings: 1}, actualResult.GetSummary())
+
+	workload.ObjectMeta.SetNamespace("prod-eu")
+	actualResult, err = ApplyAllSchemaChecks(context.Background(), &c, nil, workload)
+	assert.NoError(t, err)
+	assert.EqualValues(t, CountSummary{Warnings: 1, Dangers: 1}, actualResult.GetSummary())
+	assert.Equal(t, conf.SeverityDanger, actualResult.PodResult.ContainerResults[0].Results["readinessProbeMissing"].Severity)
+}
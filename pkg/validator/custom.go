@@ -1,19 +1,60 @@
 package validator
 
 import (
+	"context"
 	"sync"
 
-	"github.com/qri-io/jsonschema"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/fairwindsops/polaris/pkg/kube"
 )
 
-type validatorFunction func(test schemaTestCase) (bool, []jsonschema.KeyError, error)
+// CheckInput is what a Go check is given for each resource, pod or container it applies to
+type CheckInput struct {
+	// Resource is the resource being checked
+	Resource kube.GenericResource
+	// PodSpec is the pod spec of the resource, if it's a controller or a pod
+	PodSpec *corev1.PodSpec
+	// Container is set if the check's target is Container
+	Container       *corev1.Container
+	IsInitContainer bool
+	// ResourceProvider holds all the resources being audited, for checks that look at other resources.
+	// It's nil in admission control, where only the resource being admitted is available.
+	ResourceProvider *kube.ResourceProvider
+}
+
+// CheckOutcome is the result of a Go check
+type CheckOutcome struct {
+	Passes bool
+	// Message replaces the check's success or failure message, if it's set
+	Message string
+	// Details describe why the check failed. PropertyPath is relative to the checked object, e.g. the
+	// container for Container checks.
+	Details []ResultDetail
+}
+
+// CheckFunc is a check written in Go. It's called concurrently for different resources, so it must
+// not modify its input.
+type CheckFunc func(ctx context.Context, input CheckInput) (CheckOutcome, error)
 
-var validatorMapper = map[string]validatorFunction{}
-var lock = &sync.Mutex{}
+var checkFuncs = map[string]CheckFunc{}
+var lock = &sync.RWMutex{}
 
-func registerCustomChecks(name string, check validatorFunction) {
+// RegisterCheck adds a check written in Go. Its metadata (messages, category, target, and which
+// controllers and containers it applies to) comes from the built-in or custom check with the same ID,
+// which doesn't need a schema, and it's enabled by giving it a severity in the config like any other
+// check. If the check also has a schema, CEL expression, Rego policy or WebAssembly module, the function
+// is only called for resources that pass them. Checks should be registered before any audits run, e.g. in init().
+func RegisterCheck(id string, check CheckFunc) {
 	lock.Lock()
 	defer lock.Unlock()
 
-	validatorMapper[name] = check
+	checkFuncs[id] = check
+}
+
+func getCheckFunc(id string) CheckFunc {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return checkFuncs[id]
 }
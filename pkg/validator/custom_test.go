@@ -0,0 +1,91 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/kube"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var goCheckConf = `
+checks:
+  goCheck: danger
+customChecks:
+  goCheck:
+    successMessage: Go check passed
+    failureMessage: Go check failed
+    category: Security
+    target: Container
+    schema:
+      required:
+      - image
+`
+
+func TestRegisterCheck(t *testing.T) {
+	var inputs []CheckInput
+	RegisterCheck("goCheck", func(ctx context.Context, input CheckInput) (CheckOutcome, error) {
+		inputs = append(inputs, input)
+		if input.Container.Image == "error" {
+			return CheckOutcome{}, errors.New("bad image")
+		}
+		if input.Container.Image != "quay.io/foo" {
+			return CheckOutcome{
+				Message: "Image " + input.Container.Image + " isn't allowed",
+				Details: []ResultDetail{{PropertyPath: "/image", InvalidValue: input.Container.Image, Message: "isn't allowed"}},
+			}, nil
+		}
+		return CheckOutcome{Passes: true}, nil
+	})
+	defer RegisterCheck("goCheck", nil)
+	c, err := conf.Parse([]byte(goCheckConf))
+	assert.NoError(t, err)
+	workload := getEmptyWorkload(t, "foo")
+
+	// The function isn't called for containers that fail the schema
+	results, err := applyContainerSchemaChecks(context.Background(), &c, nil, workload, &corev1.Container{Name: "foo"}, false)
+	assert.NoError(t, err)
+	assert.False(t, results["goCheck"].Success)
+	assert.Equal(t, "Go check failed", results["goCheck"].Message)
+	assert.Len(t, inputs, 0)
+
+	container := &corev1.Container{Name: "foo", Image: "docker.io/foo"}
+	workload.PodSpec.Containers = []corev1.Container{*container}
+	results, err = applyContainerSchemaChecks(context.Background(), &c, nil, workload, container, false)
+	assert.NoError(t, err)
+	assert.False(t, results["goCheck"].Success)
+	assert.Equal(t, "Image docker.io/foo isn't allowed", results["goCheck"].Message)
+	assert.Equal(t, []ResultDetail{{PropertyPath: "/spec/containers/0/image", InvalidValue: "docker.io/foo", Message: "isn't allowed"}}, results["goCheck"].Details)
+	if assert.Len(t, inputs, 1) {
+		assert.Equal(t, container, inputs[0].Container)
+		assert.Equal(t, workload.ObjectMeta.GetName(), inputs[0].Resource.ObjectMeta.GetName())
+		assert.Nil(t, inputs[0].ResourceProvider)
+	}
+
+	results, err = applyContainerSchemaChecks(context.Background(), &c, &kube.ResourceProvider{}, workload, &corev1.Container{Name: "foo", Image: "quay.io/foo"}, false)
+	assert.NoError(t, err)
+	assert.True(t, results["goCheck"].Success)
+	assert.Equal(t, "Go check passed", results["goCheck"].Message)
+	assert.NotNil(t, inputs[1].ResourceProvider)
+
+	_, err = applyContainerSchemaChecks(context.Background(), &c, nil, workload, &corev1.Container{Name: "foo", Image: "error"}, false)
+	assert.EqualError(t, err, "check goCheck: bad image")
+}
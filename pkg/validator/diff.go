@@ -0,0 +1,342 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/fairwindsops/polaris/pkg/config"
+)
+
+// AuditDiff describes what changed between two audits
+type AuditDiff struct {
+	ScoreBefore      uint
+	ScoreAfter       uint
+	ScoreDelta       int
+	NewFailures      []DiffFailure
+	ResolvedFailures []DiffFailure
+	AddedResources   []DiffResource
+	RemovedResources []DiffResource
+	// NamespaceScores and CategoryScores only include namespaces and categories whose score changed
+	NamespaceScores []ScoreChange
+	CategoryScores  []ScoreChange
+}
+
+// DiffFailure is a failed check that only appears in one of the two audits
+type DiffFailure struct {
+	BaselineEntry
+	Severity config.Severity
+	Message  string
+}
+
+// DiffResource identifies a resource that only appears in one of the two audits
+type DiffResource struct {
+	Kind      string
+	Namespace string `json:",omitempty"`
+	Name      string
+}
+
+// String returns a human-readable description of the resource
+func (r DiffResource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// ScoreChange is the change in score for a namespace or category
+type ScoreChange struct {
+	Name   string
+	Before uint
+	After  uint
+	Delta  int
+}
+
+// DiffAudits compares two audits, e.g. from before and after a change
+func DiffAudits(before, after AuditData) AuditDiff {
+	diff := AuditDiff{
+		ScoreBefore:      before.GetSummary().GetScore(),
+		ScoreAfter:       after.GetSummary().GetScore(),
+		NewFailures:      []DiffFailure{},
+		ResolvedFailures: []DiffFailure{},
+		AddedResources:   []DiffResource{},
+		RemovedResources: []DiffResource{},
+	}
+	diff.ScoreDelta = int(diff.ScoreAfter) - int(diff.ScoreBefore)
+
+	beforeFailures := before.getFailures()
+	afterFailures := after.getFailures()
+	for fingerprint, failure := range afterFailures {
+		if _, ok := beforeFailures[fingerprint]; !ok {
+			diff.NewFailures = append(diff.NewFailures, failure)
+		}
+	}
+	for fingerprint, failure := range beforeFailures {
+		if _, ok := afterFailures[fingerprint]; !ok {
+			diff.ResolvedFailures = append(diff.ResolvedFailures, failure)
+		}
+	}
+	sortDiffFailures(diff.NewFailures)
+	sortDiffFailures(diff.ResolvedFailures)
+
+	beforeResources := before.getResources()
+	afterResources := after.getResources()
+	for resource := range afterResources {
+		if !beforeResources[resource] {
+			diff.AddedResources = append(diff.AddedResources, resource)
+		}
+	}
+	for resource := range beforeResources {
+		if !afterResources[resource] {
+			diff.RemovedResources = append(diff.RemovedResources, resource)
+		}
+	}
+	sortDiffResources(diff.AddedResources)
+	sortDiffResources(diff.RemovedResources)
+
+	diff.NamespaceScores = getScoreChanges(before.getSummaryByNamespace(), after.getSummaryByNamespace())
+	diff.CategoryScores = getScoreChanges(before.getSummaryByCategory(), after.getSummaryByCategory())
+	return diff
+}
+
+// HasRegressions returns true if the newer audit has new failures or a lower score
+func (d AuditDiff) HasRegressions() bool {
+	return len(d.NewFailures) > 0 || d.ScoreDelta < 0
+}
+
+func (res AuditData) getFailures() map[string]DiffFailure {
+	failures := map[string]DiffFailure{}
+	for _, result := range res.Results {
+		result.forEachResultSet(func(containerName string, resultSet ResultSet) {
+			for checkID, msg := range resultSet {
+				if msg.Success {
+					continue
+				}
+				entry := newBaselineEntry(checkID, result, containerName)
+				failures[entry.Fingerprint] = DiffFailure{
+					BaselineEntry: entry,
+					Severity:      msg.Severity,
+					Message:       msg.Message,
+				}
+			}
+		})
+	}
+	return failures
+}
+
+func (res AuditData) getResources() map[DiffResource]bool {
+	resources := map[DiffResource]bool{}
+	for _, result := range res.Results {
+		resources[DiffResource{Kind: result.Kind, Namespace: result.Namespace, Name: result.Name}] = true
+	}
+	return resources
+}
+
+func (res AuditData) getSummaryByNamespace() map[string]CountSummary {
+	summaries := map[string]CountSummary{}
+	for _, result := range res.Results {
+		summary := summaries[result.Namespace]
+		summary.AddSummary(result.GetSummary())
+		summaries[result.Namespace] = summary
+	}
+	return summaries
+}
+
+// getSummaryByCategory differs from GetSummaryByCategory by including results that have no pod
+func (res AuditData) getSummaryByCategory() map[string]CountSummary {
+	summaries := CountSummaryByCategory{}
+	for _, result := range res.Results {
+		summaries.AddSummary(result.GetSummaryByCategory())
+	}
+	return summaries
+}
+
+func getScoreChanges(before, after map[string]CountSummary) []ScoreChange {
+	names := map[string]bool{}
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+	changes := []ScoreChange{}
+	for name := range names {
+		change := ScoreChange{
+			Name:   name,
+			Before: before[name].GetScore(),
+			After:  after[name].GetScore(),
+		}
+		change.Delta = int(change.After) - int(change.Before)
+		if change.Delta != 0 {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func sortDiffFailures(failures []DiffFailure) {
+	sort.Slice(failures, func(i, j int) bool {
+		a, b := failures[i], failures[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Container != b.Container {
+			return a.Container < b.Container
+		}
+		return a.Check < b.Check
+	})
+}
+
+func sortDiffResources(resources []DiffResource) {
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].String() < resources[j].String()
+	})
+}
+
+// GetPrettyOutput returns a human-readable string
+func (d AuditDiff) GetPrettyOutput(useColor bool) string {
+	color.NoColor = !useColor
+	var str strings.Builder
+	scoreColor := color.GreenString
+	if d.ScoreDelta < 0 {
+		scoreColor = color.RedString
+	}
+	str.WriteString(titleColor.Sprint("Polaris audit diff\n"))
+	str.WriteString(scoreColor(fmt.Sprintf("    Score: %d -> %d (%+d)\n", d.ScoreBefore, d.ScoreAfter, d.ScoreDelta)))
+	str.WriteString("\n")
+
+	writeFailures := func(title string, failures []DiffFailure, colorFn func(string, ...any) string) {
+		if len(failures) == 0 {
+			return
+		}
+		str.WriteString(titleColor.Sprintf("%s (%d)\n", title, len(failures)))
+		for _, failure := range failures {
+			str.WriteString(colorFn("    %s [%s]\n", failure.BaselineEntry, failure.Severity))
+			str.WriteString(fmt.Sprintf("        %s\n", failure.Message))
+		}
+		str.WriteString("\n")
+	}
+	writeFailures("New failures", d.NewFailures, color.RedString)
+	writeFailures("Resolved failures", d.ResolvedFailures, color.GreenString)
+
+	writeResources := func(title string, resources []DiffResource) {
+		if len(resources) == 0 {
+			return
+		}
+		str.WriteString(titleColor.Sprintf("%s (%d)\n", title, len(resources)))
+		for _, resource := range resources {
+			str.WriteString(fmt.Sprintf("    %s\n", resource))
+		}
+		str.WriteString("\n")
+	}
+	writeResources("Added resources", d.AddedResources)
+	writeResources("Removed resources", d.RemovedResources)
+
+	writeScores := func(title string, changes []ScoreChange) {
+		if len(changes) == 0 {
+			return
+		}
+		str.WriteString(titleColor.Sprintf("%s\n", title))
+		for _, change := range changes {
+			colorFn := color.GreenString
+			if change.Delta < 0 {
+				colorFn = color.RedString
+			}
+			str.WriteString(colorFn("    %s: %d -> %d (%+d)\n", getDisplayName(change.Name), change.Before, change.After, change.Delta))
+		}
+		str.WriteString("\n")
+	}
+	writeScores("Score by namespace", d.NamespaceScores)
+	writeScores("Score by category", d.CategoryScores)
+	color.NoColor = false
+	return str.String()
+}
+
+// GetMarkdownOutput returns the diff as Markdown, e.g. for commenting on a pull request
+func (d AuditDiff) GetMarkdownOutput() string {
+	var str strings.Builder
+	str.WriteString("## Polaris audit diff\n\n")
+	str.WriteString(fmt.Sprintf("**Score:** %d → %d (%+d)\n\n", d.ScoreBefore, d.ScoreAfter, d.ScoreDelta))
+
+	writeFailures := func(title string, failures []DiffFailure) {
+		if len(failures) == 0 {
+			return
+		}
+		str.WriteString(fmt.Sprintf("### %s (%d)\n\n", title, len(failures)))
+		str.WriteString("| Resource | Container | Check | Severity | Message |\n")
+		str.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, failure := range failures {
+			resource := DiffResource{Kind: failure.Kind, Namespace: failure.Namespace, Name: failure.Name}
+			str.WriteString(fmt.Sprintf("| %s | %s | `%s` | %s | %s |\n", resource, failure.Container, failure.Check, failure.Severity, escapeMarkdownTableCell(failure.Message)))
+		}
+		str.WriteString("\n")
+	}
+	writeFailures("New failures", d.NewFailures)
+	writeFailures("Resolved failures", d.ResolvedFailures)
+
+	writeResources := func(title string, resources []DiffResource) {
+		if len(resources) == 0 {
+			return
+		}
+		str.WriteString(fmt.Sprintf("### %s (%d)\n\n", title, len(resources)))
+		for _, resource := range resources {
+			str.WriteString(fmt.Sprintf("- %s\n", resource))
+		}
+		str.WriteString("\n")
+	}
+	writeResources("Added resources", d.AddedResources)
+	writeResources("Removed resources", d.RemovedResources)
+
+	writeScores := func(title, column string, changes []ScoreChange) {
+		if len(changes) == 0 {
+			return
+		}
+		str.WriteString(fmt.Sprintf("### %s\n\n", title))
+		str.WriteString(fmt.Sprintf("| %s | Before | After | Change |\n", column))
+		str.WriteString("| --- | --- | --- | --- |\n")
+		for _, change := range changes {
+			str.WriteString(fmt.Sprintf("| %s | %d | %d | %+d |\n", getDisplayName(change.Name), change.Before, change.After, change.Delta))
+		}
+		str.WriteString("\n")
+	}
+	writeScores("Score by namespace", "Namespace", d.NamespaceScores)
+	writeScores("Score by category", "Category", d.CategoryScores)
+	return str.String()
+}
+
+// getDisplayName names the empty namespace or category
+func getDisplayName(name string) string {
+	if name == "" {
+		return "(none)"
+	}
+	return name
+}
+
+func escapeMarkdownTableCell(text string) string {
+	return strings.ReplaceAll(text, "|", "\\|")
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffAudits(t *testing.T) {
+	before := getBaselineTestAudit()
+	before.Results = append(before.Results, Result{
+		Name:      "old",
+		Namespace: "legacy",
+		Kind:      "Service",
+		Results:   ResultSet{},
+	})
+
+	after := getBaselineTestAudit()
+	after.Results[0].Results = ResultSet{
+		"deploymentMissingReplicas": {ID: "deploymentMissingReplicas", Success: true, Severity: conf.SeverityWarning, Category: "Reliability"},
+	}
+	after.Results[0].PodResult.Results = ResultSet{
+		"hostIPCSet": {ID: "hostIPCSet", Message: "Host IPC should not be configured", Success: false, Severity: conf.SeverityDanger, Category: "Security"},
+	}
+	after.Results = append(after.Results, Result{
+		Name:    "admin",
+		Kind:    "ClusterRole",
+		Results: ResultSet{},
+	})
+
+	diff := DiffAudits(before, after)
+	assert.Equal(t, uint(40), diff.ScoreBefore)
+	assert.Equal(t, uint(33), diff.ScoreAfter)
+	assert.Equal(t, -7, diff.ScoreDelta)
+	assert.True(t, diff.HasRegressions())
+
+	if assert.Len(t, diff.NewFailures, 1) {
+		assert.Equal(t, "hostIPCSet", diff.NewFailures[0].Check)
+		assert.Equal(t, conf.SeverityDanger, diff.NewFailures[0].Severity)
+	}
+	if assert.Len(t, diff.ResolvedFailures, 1) {
+		assert.Equal(t, "deploymentMissingReplicas", diff.ResolvedFailures[0].Check)
+	}
+	assert.Equal(t, []DiffResource{{Kind: "ClusterRole", Name: "admin"}}, diff.AddedResources)
+	assert.Equal(t, []DiffResource{{Kind: "Service", Namespace: "legacy", Name: "old"}}, diff.RemovedResources)
+	assert.Equal(t, []ScoreChange{{Name: "default", Before: 40, After: 33, Delta: -7}}, diff.NamespaceScores)
+
+	markdown := diff.GetMarkdownOutput()
+	assert.Contains(t, markdown, "**Score:** 40 → 33 (-7)")
+	assert.Contains(t, markdown, "| Deployment/default/web |  | `hostIPCSet` | danger | Host IPC should not be configured |")
+	assert.Contains(t, markdown, "- ClusterRole/admin")
+
+	pretty := diff.GetPrettyOutput(false)
+	assert.True(t, strings.Contains(pretty, "New failures (1)"))
+	assert.True(t, strings.Contains(pretty, "Removed resources (1)"))
+}
+
+func TestDiffAuditsUnchanged(t *testing.T) {
+	diff := DiffAudits(getBaselineTestAudit(), getBaselineTestAudit())
+	assert.False(t, diff.HasRegressions())
+	assert.Empty(t, diff.NewFailures)
+	assert.Empty(t, diff.ResolvedFailures)
+	assert.Empty(t, diff.NamespaceScores)
+	assert.Empty(t, diff.CategoryScores)
+}
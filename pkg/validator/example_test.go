@@ -0,0 +1,76 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/kube"
+	"github.com/fairwindsops/polaris/pkg/validator"
+)
+
+// This example embeds Polaris in another program, adding a check written in Go to the built-in ones
+func ExampleRegisterCheck() {
+	validator.RegisterCheck("imageTagPinned", func(ctx context.Context, input validator.CheckInput) (validator.CheckOutcome, error) {
+		image := input.Container.Image
+		if !strings.Contains(image, ":") || strings.HasSuffix(image, ":latest") {
+			return validator.CheckOutcome{Message: fmt.Sprintf("Image %s should be pinned to a tag", image)}, nil
+		}
+		return validator.CheckOutcome{Passes: true}, nil
+	})
+
+	// The check's metadata and severity are configured like any other custom check, but without a schema
+	c, err := config.Parse([]byte(`
+checks:
+  imageTagPinned: warning
+customChecks:
+  imageTagPinned:
+    successMessage: Image is pinned to a tag
+    failureMessage: Image should be pinned to a tag
+    category: Reliability
+    target: Container
+`))
+	if err != nil {
+		panic(err)
+	}
+	resources, err := kube.CreateResourceProviderFromPath("../kube/test_files/test_1")
+	if err != nil {
+		panic(err)
+	}
+	audit, err := validator.RunAudit(context.Background(), c, resources)
+	if err != nil {
+		panic(err)
+	}
+	for _, result := range audit.Results {
+		if result.PodResult == nil {
+			continue
+		}
+		for _, container := range result.PodResult.ContainerResults {
+			fmt.Printf("%s %s/%s: %s\n", result.Kind, result.Name, container.Name, container.Results["imageTagPinned"].Message)
+		}
+	}
+	// Output:
+	// Pod hello-world/hello: Image is pinned to a tag
+	// Pod hello-world/hello: Image is pinned to a tag
+	// DaemonSet test/test: Image busybox should be pinned to a tag
+	// Deployment test-deployment/ubuntu: Image ubuntu should be pinned to a tag
+	// Deployment test-deployment-2/ubuntu: Image ubuntu should be pinned to a tag
+	// StatefulSet web/nginx: Image is pinned to a tag
+	// CronJob test/test: Image busybox should be pinned to a tag
+	// Job test/test: Image alpine should be pinned to a tag
+}
@@ -0,0 +1,244 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/kube"
+)
+
+// exemptionExpiryWarning is how soon before expiring an exemption is listed in ExpiringExemptions
+const exemptionExpiryWarning = 30 * 24 * time.Hour
+
+// ExemptedCheck records a check that a config exemption kept from running
+type ExemptedCheck struct {
+	ID string
+	// Container is empty for checks on the controller or pod spec
+	Container string `json:",omitempty"`
+	ResultExemption
+}
+
+// ExpiringExemption is a config exemption that has expired, or will expire soon
+type ExpiringExemption struct {
+	config.Exemption
+	// Index is the position of the exemption in the config's exemptions list
+	Index   int
+	Expired bool
+}
+
+// String returns a human-readable description of the exemption
+func (e ExpiringExemption) String() string {
+	str := fmt.Sprintf("exemption %d", e.Index)
+	if e.Expired {
+		str += " expired " + e.Expires
+	} else {
+		str += " expires " + e.Expires
+	}
+	if e.Owner != "" {
+		str += ", owner " + e.Owner
+	}
+	if e.Ticket != "" {
+		str += ", ticket " + e.Ticket
+	}
+	if e.Reason != "" {
+		str += ": " + e.Reason
+	}
+	return str
+}
+
+// recordExemptions lists the checks that config exemptions kept from running in Exemptions, and removes
+// their results unless exempted results are kept
+func (res Result) recordExemptions(conf *config.Configuration) Result {
+	res.Exemptions = res.getExemptedChecks()
+	// Without includeExempted or reportStaleExemptions, only config exemptions are kept in the results
+	if len(res.Exemptions) > 0 && !conf.IncludeExempted && !conf.ReportStaleExemptions {
+		return res.removeExemptedResults()
+	}
+	return res
+}
+
+// getExemptedChecks lists the checks that config exemptions kept from running on a resource
+func (res Result) getExemptedChecks() []ExemptedCheck {
+	var exempted []ExemptedCheck
+	res.forEachResultSet(func(containerName string, resultSet ResultSet) {
+		for checkID, msg := range resultSet {
+			if msg.Exemption != nil && msg.Exemption.Source == ExemptionSourceConfig {
+				exempted = append(exempted, ExemptedCheck{ID: checkID, Container: containerName, ResultExemption: *msg.Exemption})
+			}
+		}
+	})
+	// Sort by check, keeping each check's results in the order of the resource's containers
+	slices.SortStableFunc(exempted, func(a, b ExemptedCheck) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return exempted
+}
+
+// getExpiringExemptions lists the config exemptions that have expired, or expire within exemptionExpiryWarning of now
+func getExpiringExemptions(conf config.Configuration, now time.Time) []ExpiringExemption {
+	var expiring []ExpiringExemption
+	for idx, exemption := range conf.Exemptions {
+		expiry, err := exemption.GetExpiry()
+		if err != nil || expiry.IsZero() || expiry.Sub(now) > exemptionExpiryWarning {
+			continue
+		}
+		expiring = append(expiring, ExpiringExemption{
+			Exemption: exemption,
+			Index:     idx,
+			Expired:   exemption.IsExpired(now),
+		})
+	}
+	return expiring
+}
+
+// ExemptionUsage describes what an exemption applied to during an audit
+type ExemptionUsage struct {
+	Source ExemptionSource
+	// ConfigIndex is the position of the exemption in the config's exemptions list, for config exemptions
+	ConfigIndex int `json:",omitempty"`
+	// Annotation, Kind, Namespace and Name locate annotation exemptions
+	Annotation string `json:",omitempty"`
+	Kind       string `json:",omitempty"`
+	Namespace  string `json:",omitempty"`
+	Name       string `json:",omitempty"`
+	// Matched is how many checks the exemption kept from running
+	Matched int
+	// Suppressed is how many of those checks would have failed
+	Suppressed int
+}
+
+// String returns a human-readable description of the exemption's usage
+func (u ExemptionUsage) String() string {
+	str := fmt.Sprintf("config exemption %d", u.ConfigIndex)
+	if u.Source == ExemptionSourceAnnotation {
+		str = fmt.Sprintf("annotation %s on %s %s", u.Annotation, u.Kind, u.Name)
+		if u.Namespace != "" {
+			str += " in namespace " + u.Namespace
+		}
+	}
+	if u.Matched == 0 {
+		return str + " matched no checks"
+	}
+	return str + fmt.Sprintf(" matched %d checks, which would all have passed", u.Matched)
+}
+
+// getStaleExemptions lists the exemptions that matched no checks, or only matched checks that would have
+// passed anyway. Results need to come from an audit with conf.ReportStaleExemptions set.
+func getStaleExemptions(conf config.Configuration, resources *kube.ResourceProvider, results []Result) []ExemptionUsage {
+	var configUsage []ExemptionUsage
+	if !conf.DisallowExemptions && !conf.DisallowConfigExemptions {
+		for idx := range conf.Exemptions {
+			configUsage = append(configUsage, ExemptionUsage{Source: ExemptionSourceConfig, ConfigIndex: idx})
+		}
+	}
+	var annotationUsage []ExemptionUsage
+	annotationIndexes := map[string]int{}
+	annotationKey := func(kind, namespace, name, annotation string) string {
+		return strings.Join([]string{kind, namespace, name, annotation}, "/")
+	}
+	if !conf.DisallowExemptions && !conf.DisallowAnnotationExemptions {
+		for _, resource := range getSortedResources(resources) {
+			annotations := resource.ObjectMeta.GetAnnotations()
+			keys := make([]string, 0, len(annotations))
+			for key, value := range annotations {
+				if isExemptionAnnotation(key) && strings.ToLower(value) == "true" {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				usage := ExemptionUsage{
+					Source:     ExemptionSourceAnnotation,
+					Annotation: key,
+					Kind:       resource.Kind,
+					Namespace:  resource.ObjectMeta.GetNamespace(),
+					Name:       resource.ObjectMeta.GetName(),
+				}
+				annotationIndexes[annotationKey(usage.Kind, usage.Namespace, usage.Name, key)] = len(annotationUsage)
+				annotationUsage = append(annotationUsage, usage)
+			}
+		}
+	}
+
+	for _, result := range results {
+		result.forEachResultSet(func(containerName string, resultSet ResultSet) {
+			for _, msg := range resultSet {
+				if msg.Exemption == nil {
+					continue
+				}
+				var usage *ExemptionUsage
+				if msg.Exemption.Source == ExemptionSourceConfig && msg.Exemption.ConfigIndex < len(configUsage) {
+					usage = &configUsage[msg.Exemption.ConfigIndex]
+				} else if idx, ok := annotationIndexes[annotationKey(result.Kind, result.Namespace, result.Name, msg.Exemption.Annotation)]; ok {
+					usage = &annotationUsage[idx]
+				}
+				if usage == nil {
+					continue
+				}
+				usage.Matched++
+				if msg.Exemption.Suppressed {
+					usage.Suppressed++
+				}
+			}
+		})
+	}
+
+	var stale []ExemptionUsage
+	for _, usage := range append(configUsage, annotationUsage...) {
+		if usage.Suppressed == 0 {
+			stale = append(stale, usage)
+		}
+	}
+	return stale
+}
+
+// isExemptionAnnotation returns true for annotation keys that exempt resources from checks
+func isExemptionAnnotation(key string) bool {
+	if key == exemptionAnnotationKey {
+		return true
+	}
+	prefix, _, _ := strings.Cut(exemptionAnnotationPattern, "%s")
+	return strings.HasPrefix(key, prefix) && strings.HasSuffix(key, "-exempt")
+}
+
+// removeExemptedResults removes the results that exemptions kept from running
+func (res AuditData) removeExemptedResults() AuditData {
+	resCopy := res
+	resCopy.Results = make([]Result, len(res.Results))
+	for idx, result := range res.Results {
+		resCopy.Results[idx] = result.removeExemptedResults()
+	}
+	return resCopy
+}
+
+// removeExemptedResults removes the results that exemptions kept from running. Exemptions still lists
+// the checks that config exemptions kept from running.
+func (res Result) removeExemptedResults() Result {
+	return res.copyResultSets(func(_ string, resultSet ResultSet) ResultSet {
+		filtered := ResultSet{}
+		for checkID, msg := range resultSet {
+			if !msg.Exempted {
+				filtered[checkID] = msg
+			}
+		}
+		return filtered
+	})
+}
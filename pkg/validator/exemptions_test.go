@@ -0,0 +1,179 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/kube"
+	"github.com/fairwindsops/polaris/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExemptedChecks(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"hostIPCSet":           conf.SeverityDanger,
+			"livenessProbeMissing": conf.SeverityWarning,
+		},
+		Exemptions: []conf.Exemption{{
+			Rules:           []string{"livenessProbeMissing"},
+			ControllerNames: []string{"deploy"},
+			Owner:           "platform-team",
+			Reason:          "batch worker",
+			Expires:         time.Now().Add(time.Hour).Format(time.RFC3339),
+		}, {
+			Rules:   []string{"hostIPCSet"},
+			Expires: "2020-01-01",
+		}},
+	}
+	deploy, pod := test.MockDeploy("test", "deploy")
+	resource, err := kube.NewGenericResourceFromPod(pod, deploy)
+	assert.NoError(t, err)
+
+	result, err := ApplyAllSchemaChecks(context.Background(), &c, nil, resource)
+	assert.NoError(t, err)
+	assert.Contains(t, result.PodResult.Results, "hostIPCSet")
+	assert.NotContains(t, result.PodResult.ContainerResults[0].Results, "livenessProbeMissing")
+	assert.Equal(t, []ExemptedCheck{{
+		ID:        "livenessProbeMissing",
+		Container: pod.Spec.Containers[0].Name,
+		ResultExemption: ResultExemption{
+			Source:      ExemptionSourceConfig,
+			ConfigIndex: 0,
+			Owner:       "platform-team",
+			Reason:      "batch worker",
+			Expires:     c.Exemptions[0].Expires,
+		},
+	}}, result.Exemptions)
+	assert.Equal(t, 1, strings.Count(result.GetPrettyOutput(), "exempted by config exemption 0: batch worker"))
+
+	// The exemption is listed once when exempted results are kept too
+	c.IncludeExempted = true
+	result, err = ApplyAllSchemaChecks(context.Background(), &c, nil, resource)
+	assert.NoError(t, err)
+	assert.True(t, result.PodResult.ContainerResults[0].Results["livenessProbeMissing"].Exempted)
+	assert.Len(t, result.Exemptions, 1)
+	assert.Equal(t, 1, strings.Count(result.GetPrettyOutput(), "exempted by config exemption 0: batch worker"))
+
+	expiring := getExpiringExemptions(c, time.Now())
+	if assert.Len(t, expiring, 2) {
+		assert.False(t, expiring[0].Expired)
+		assert.Equal(t, 1, expiring[1].Index)
+		assert.True(t, expiring[1].Expired)
+		assert.Equal(t, "exemption 1 expired 2020-01-01", expiring[1].String())
+	}
+}
+
+func TestNamespaceSelectorExemption(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"hostIPCSet": conf.SeverityDanger,
+		},
+		Exemptions: []conf.Exemption{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "system"}},
+		}},
+	}
+	namespace := test.MockNamespace("test")
+	namespace.Labels = map[string]string{"tier": "system"}
+	resources := &kube.ResourceProvider{Namespaces: []corev1.Namespace{namespace}}
+	deploy, pod := test.MockDeploy("test", "deploy")
+	resource, err := kube.NewGenericResourceFromPod(pod, deploy)
+	assert.NoError(t, err)
+
+	result, err := ApplyAllSchemaChecks(context.Background(), &c, resources, resource)
+	assert.NoError(t, err)
+	assert.NotContains(t, result.PodResult.Results, "hostIPCSet")
+	assert.Len(t, result.Exemptions, 1)
+
+	resources.Namespaces[0].Labels = nil
+	result, err = ApplyAllSchemaChecks(context.Background(), &c, resources, resource)
+	assert.NoError(t, err)
+	assert.Contains(t, result.PodResult.Results, "hostIPCSet")
+	assert.Empty(t, result.Exemptions)
+}
+
+func TestAnnotationExemptionUnknownCheck(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"hostIPCSet":   conf.SeverityDanger,
+			"retiredCheck": conf.SeverityWarning,
+		},
+		IncludeExempted: true,
+	}
+	deploy, pod := test.MockDeploy("test", "deploy")
+	deploy.Annotations = map[string]string{"polaris.fairwinds.com/exempt": "true"}
+	resource, err := kube.NewGenericResourceFromPod(pod, deploy)
+	assert.NoError(t, err)
+
+	// Annotated resources skip checks that have no definition, as they did before exempted results were kept
+	result, err := ApplyAllSchemaChecks(context.Background(), &c, nil, resource)
+	assert.NoError(t, err)
+	assert.True(t, result.PodResult.Results["hostIPCSet"].Exempted)
+	assert.NotContains(t, result.Results, "retiredCheck")
+
+	deploy.Annotations = nil
+	resource, err = kube.NewGenericResourceFromPod(pod, deploy)
+	assert.NoError(t, err)
+	_, err = ApplyAllSchemaChecks(context.Background(), &c, nil, resource)
+	assert.EqualError(t, err, "Check retiredCheck not found")
+}
+
+func TestStaleExemptions(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"hostIPCSet":           conf.SeverityDanger,
+			"livenessProbeMissing": conf.SeverityWarning,
+		},
+		Exemptions: []conf.Exemption{
+			{Rules: []string{"livenessProbeMissing"}, Namespace: "test"},
+			{Rules: []string{"hostIPCSet"}},
+			{Namespace: "nowhere"},
+		},
+		ReportStaleExemptions: true,
+	}
+	objects := test.GetMockControllers("test")
+	objMeta, err := meta.Accessor(objects[0])
+	assert.NoError(t, err)
+	objMeta.SetAnnotations(map[string]string{"polaris.fairwinds.com/hostIPCSet-exempt": "true"})
+	k8s, dynamicClient := test.SetupTestAPI(objects...)
+	resources, err := kube.CreateResourceProviderFromAPI(context.Background(), k8s, "test", dynamicClient, c)
+	assert.NoError(t, err)
+
+	auditData, err := RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), auditData.GetSummary().Exempted)
+	if assert.Len(t, auditData.StaleExemptions, 3) {
+		assert.Equal(t, ExemptionUsage{Source: ExemptionSourceConfig, ConfigIndex: 1, Matched: 4}, auditData.StaleExemptions[0])
+		assert.Equal(t, ExemptionUsage{Source: ExemptionSourceConfig, ConfigIndex: 2}, auditData.StaleExemptions[1])
+		assert.Equal(t, "config exemption 2 matched no checks", auditData.StaleExemptions[1].String())
+		assert.Equal(t, ExemptionSourceAnnotation, auditData.StaleExemptions[2].Source)
+		assert.Equal(t, "polaris.fairwinds.com/hostIPCSet-exempt", auditData.StaleExemptions[2].Annotation)
+		assert.Equal(t, 1, auditData.StaleExemptions[2].Matched)
+	}
+
+	c.IncludeExempted = true
+	auditData, err = RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(8), auditData.GetSummary().Exempted)
+	assert.Len(t, auditData.StaleExemptions, 3)
+}
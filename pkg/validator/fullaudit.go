@@ -31,15 +31,18 @@ import (
 
 // RunAudit runs a full Polaris audit and returns an AuditData object
 func RunAudit(ctx context.Context, config conf.Configuration, kubeResources *kube.ResourceProvider) (AuditData, error) {
-	displayName := config.DisplayName
-	if displayName == "" {
-		displayName = kubeResources.SourceName
-	}
-
 	results, err := ApplyAllSchemaChecksToResourceProvider(ctx, &config, kubeResources)
 	if err != nil {
 		return AuditData{}, err
 	}
+	return newAuditData(config, kubeResources, results), nil
+}
+
+func newAuditData(config conf.Configuration, kubeResources *kube.ResourceProvider, results []Result) AuditData {
+	displayName := config.DisplayName
+	if displayName == "" {
+		displayName = kubeResources.SourceName
+	}
 
 	auditData := AuditData{
 		PolarisOutputVersion: PolarisOutputVersion,
@@ -54,10 +57,22 @@ func RunAudit(ctx context.Context, config conf.Configuration, kubeResources *kub
 			Namespaces:  len(kubeResources.Namespaces),
 			Controllers: kubeResources.Resources.GetNumberOfControllers(),
 		},
-		Results: results,
+		Results:            results,
+		ExpiringExemptions: getExpiringExemptions(config, time.Now()),
+	}
+	if config.ReportStaleExemptions {
+		auditData.StaleExemptions = getStaleExemptions(config, kubeResources, results)
+		if !config.IncludeExempted {
+			auditData = auditData.removeExemptedResults()
+		}
+	}
+	if configHash, err := config.Hash(); err == nil {
+		auditData.ConfigHash = configHash
+	} else {
+		logrus.Warnf("Couldn't hash the configuration: %v", err)
 	}
 	auditData.Score = auditData.GetSummary().GetScore()
-	return auditData, nil
+	return auditData
 }
 
 // ReadAuditFromFile reads the data from a past audit stored in a JSON or YAML file.
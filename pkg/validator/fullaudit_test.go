@@ -54,6 +54,9 @@ func TestGetTemplateData(t *testing.T) {
 	assert.Equal(t, 5, actualAudit.ClusterInfo.Pods, "should report pod count from the cluster")
 	assert.Equal(t, 5, actualAudit.ClusterInfo.Controllers, "should report controller count from the cluster")
 	assert.Equal(t, 1, actualAudit.ClusterInfo.Namespaces, "should report namespace count from the cluster")
+	configHash, err := c.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, configHash, actualAudit.ConfigHash, "should identify the configuration")
 
 	expectedResults := []struct {
 		kind    string
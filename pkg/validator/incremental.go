@@ -0,0 +1,185 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/kube"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// dependencyKinds are looked up by Go validators, in addition to the kinds checks list in their additional schemas,
+// and namespaces if exemptions select them by label
+var dependencyKinds = []string{"policy/PodDisruptionBudget", "autoscaling/HorizontalPodAutoscaler"}
+
+// IncrementalAuditor runs audits that only re-validate resources which changed since the previous audit.
+// Results are cached by UID and resourceVersion, so resources read from files are always re-validated.
+// Cached results are dropped when the configuration changes, or when a resource that checks look up
+// (e.g. a PodDisruptionBudget) changes in the same namespace.
+type IncrementalAuditor struct {
+	mutex      sync.Mutex
+	configHash string
+	cache      map[types.UID]cachedResult
+}
+
+type cachedResult struct {
+	resourceVersion string
+	dependencyHash  string
+	result          Result
+}
+
+// NewIncrementalAuditor creates an IncrementalAuditor with an empty cache
+func NewIncrementalAuditor() *IncrementalAuditor {
+	return &IncrementalAuditor{cache: map[types.UID]cachedResult{}}
+}
+
+// RunAudit runs a full Polaris audit, reusing cached results for resources that have not changed
+func (a *IncrementalAuditor) RunAudit(ctx context.Context, config conf.Configuration, kubeResources *kube.ResourceProvider) (AuditData, error) {
+	if kubeResources == nil {
+		return AuditData{}, errors.New("No resource provider set, cannot apply schema checks")
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	// Exemptions that expired since the last audit change the results, just like a config change
+	var expired []int
+	for idx, exemption := range config.Exemptions {
+		if exemption.IsExpired(time.Now()) {
+			expired = append(expired, idx)
+		}
+	}
+	configHash, err := hashJSON([]any{config, expired})
+	if err != nil || configHash != a.configHash {
+		a.cache = map[types.UID]cachedResult{}
+	}
+	a.configHash = configHash
+
+	dependencies := newDependencyHasher(config, kubeResources)
+	resources := getSortedResources(kubeResources)
+	entries := make([]cachedResult, len(resources))
+	var stale []kube.GenericResource
+	var staleIndexes []int
+	for idx, resource := range resources {
+		namespace := resource.ObjectMeta.GetNamespace()
+		if resource.Kind == "Namespace" {
+			namespace = resource.ObjectMeta.GetName()
+		}
+		entries[idx] = cachedResult{
+			resourceVersion: resource.ObjectMeta.GetResourceVersion(),
+			dependencyHash:  dependencies.getHash(namespace),
+		}
+		cached, ok := a.cache[resource.ObjectMeta.GetUID()]
+		if ok && isCacheable(resource) && cached.resourceVersion == entries[idx].resourceVersion && cached.dependencyHash == entries[idx].dependencyHash {
+			entries[idx].result = cached.result
+		} else {
+			stale = append(stale, resource)
+			staleIndexes = append(staleIndexes, idx)
+		}
+	}
+	staleResults, err := applyAllSchemaChecksInParallel(ctx, &config, kubeResources, stale)
+	if err != nil {
+		return AuditData{}, err
+	}
+	for idx, result := range staleResults {
+		entries[staleIndexes[idx]].result = result
+	}
+
+	results := []Result{}
+	cache := map[types.UID]cachedResult{}
+	for idx, entry := range entries {
+		if isCacheable(resources[idx]) {
+			cache[resources[idx].ObjectMeta.GetUID()] = entry
+		}
+		if entry.result.Kind != "" && entry.result.Name != "" {
+			results = append(results, entry.result)
+		}
+	}
+	// Resources that are gone are dropped from the cache
+	a.cache = cache
+	logrus.Debugf("Re-validated %d of %d resources", len(stale), len(resources))
+	return newAuditData(config, kubeResources, results), nil
+}
+
+// isCacheable returns false for resources that weren't read from a cluster, e.g. from files
+func isCacheable(resource kube.GenericResource) bool {
+	return resource.ObjectMeta.GetUID() != "" && resource.ObjectMeta.GetResourceVersion() != ""
+}
+
+// dependencyHasher summarizes the resources that checks may look up for resources in a given namespace
+type dependencyHasher struct {
+	kinds     []string
+	resources *kube.ResourceProvider
+	hashes    map[string]string
+}
+
+func newDependencyHasher(config conf.Configuration, resources *kube.ResourceProvider) *dependencyHasher {
+	kinds := append([]string{}, dependencyKinds...)
+	addKinds := func(check conf.SchemaCheck) {
+		kinds = append(kinds, check.RelatedKinds()...)
+	}
+	if config.UsesNamespaceSelectors() {
+		kinds = append(kinds, "Namespace")
+	}
+	for _, check := range config.CustomChecks {
+		addKinds(check)
+	}
+	for _, check := range conf.BuiltInChecks {
+		addKinds(check)
+	}
+	sort.Strings(kinds)
+	return &dependencyHasher{kinds: kinds, resources: resources, hashes: map[string]string{}}
+}
+
+func (d *dependencyHasher) getHash(namespace string) string {
+	if hash, ok := d.hashes[namespace]; ok {
+		return hash
+	}
+	h := sha256.New()
+	for idx, kind := range d.kinds {
+		if idx > 0 && d.kinds[idx-1] == kind {
+			continue
+		}
+		for _, res := range d.resources.Resources[kind] {
+			if kind == "Namespace" && res.ObjectMeta.GetName() != namespace {
+				continue
+			}
+			if res.ObjectMeta.GetNamespace() == "" || res.ObjectMeta.GetNamespace() == namespace {
+				h.Write([]byte(kind + "/" + string(res.ObjectMeta.GetUID()) + "/" + res.ObjectMeta.GetResourceVersion() + "\n"))
+			}
+		}
+	}
+	d.hashes[namespace] = hex.EncodeToString(h.Sum(nil))
+	return d.hashes[namespace]
+}
+
+func hashJSON(v any) (string, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
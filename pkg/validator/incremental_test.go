@@ -0,0 +1,83 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"testing"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/kube"
+	"github.com/fairwindsops/polaris/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestIncrementalAuditor(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"livenessProbeMissing": conf.SeverityWarning,
+		},
+	}
+	objects := test.GetMockControllers("test")
+	for _, obj := range objects {
+		objMeta, err := meta.Accessor(obj)
+		assert.NoError(t, err)
+		objMeta.SetUID(types.UID(objMeta.GetName() + "-uid"))
+		objMeta.SetResourceVersion("1")
+	}
+	k8s, dynamicClient := test.SetupTestAPI(objects...)
+	resources, err := kube.CreateResourceProviderFromAPI(context.Background(), k8s, "test", dynamicClient, c)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	auditor := NewIncrementalAuditor()
+	fullAudit, err := RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	incrementalAudit, err := auditor.RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	assert.Equal(t, fullAudit.GetSummary(), incrementalAudit.GetSummary())
+	assert.Equal(t, fullAudit.ClusterInfo, incrementalAudit.ClusterInfo)
+	assert.Len(t, auditor.cache, 5)
+
+	deploy := resources.Resources["apps/Deployment"][0]
+	deploy.PodSpec.Containers[0].LivenessProbe = &corev1.Probe{}
+
+	// Unchanged resource versions are served from the cache
+	incrementalAudit, err = auditor.RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), incrementalAudit.GetSummary().Warnings)
+
+	deploy.ObjectMeta.SetResourceVersion("2")
+	incrementalAudit, err = auditor.RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), incrementalAudit.GetSummary().Warnings)
+
+	// Changing the configuration invalidates the cache
+	c.Checks["livenessProbeMissing"] = conf.SeverityDanger
+	incrementalAudit, err = auditor.RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), incrementalAudit.GetSummary().Warnings)
+	assert.Equal(t, uint(2), incrementalAudit.GetSummary().Dangers)
+
+	// Deleted resources are dropped from the cache
+	delete(resources.Resources, "apps/Deployment")
+	_, err = auditor.RunAudit(context.Background(), c, resources)
+	assert.NoError(t, err)
+	assert.Len(t, auditor.cache, 4)
+}
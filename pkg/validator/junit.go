@@ -0,0 +1,134 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fairwindsops/polaris/pkg/config"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// GetJUnitOutput returns the audit as a JUnit XML report, with one test suite per resource
+// and one test case per check
+func (res AuditData) GetJUnitOutput() ([]byte, error) {
+	report := junitTestSuites{
+		Name:   "Polaris",
+		Suites: []junitTestSuite{},
+	}
+	for _, result := range res.Results {
+		suite := result.getJUnitTestSuite(res.AuditTime)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+	output, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(output, '\n')...), nil
+}
+
+func (res Result) getJUnitTestSuite(timestamp string) junitTestSuite {
+	nameParts := []string{res.Kind}
+	if res.Namespace != "" {
+		nameParts = append(nameParts, res.Namespace)
+	}
+	nameParts = append(nameParts, res.Name)
+	suiteName := strings.Join(nameParts, "/")
+
+	suite := junitTestSuite{
+		Name:      suiteName,
+		Timestamp: timestamp,
+		TestCases: []junitTestCase{},
+	}
+	suite.addTestCases(suiteName, res.Results)
+	if res.PodResult != nil {
+		suite.addTestCases(suiteName+"/pod", res.PodResult.Results)
+		for _, containerResult := range res.PodResult.ContainerResults {
+			suite.addTestCases(suiteName+"/container/"+containerResult.Name, containerResult.Results)
+		}
+	}
+	return suite
+}
+
+func (suite *junitTestSuite) addTestCases(className string, resultSet ResultSet) {
+	checkIDs := make([]string, 0, len(resultSet))
+	for checkID := range resultSet {
+		checkIDs = append(checkIDs, checkID)
+	}
+	sort.Strings(checkIDs)
+	for _, checkID := range checkIDs {
+		msg := resultSet[checkID]
+		testCase := junitTestCase{
+			Name:      msg.ID,
+			ClassName: className,
+		}
+		suite.Tests++
+		if msg.Severity == config.SeverityIgnore {
+			testCase.Skipped = &junitSkipped{Message: msg.Message}
+			suite.Skipped++
+		} else if msg.Exemption != nil {
+			testCase.Skipped = &junitSkipped{Message: msg.Exemption.String()}
+			suite.Skipped++
+		} else if !msg.Success {
+			testCase.Failure = &junitFailure{
+				Message: msg.Message,
+				Type:    string(msg.Severity),
+				Text:    fmt.Sprintf("%s (%s): %s", msg.Category, msg.Severity, msg.Message),
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJUnitOutput(t *testing.T) {
+	auditData := AuditData{
+		AuditTime: "2022-01-01T00:00:00Z",
+		Results: []Result{
+			{
+				Name:      "web",
+				Namespace: "default",
+				Kind:      "Deployment",
+				Results: ResultSet{
+					"deploymentMissingReplicas": {ID: "deploymentMissingReplicas", Message: "Only one replica is scheduled", Success: false, Severity: conf.SeverityWarning, Category: "Reliability"},
+				},
+				PodResult: &PodResult{
+					Results: ResultSet{
+						"hostIPCSet": {ID: "hostIPCSet", Message: "Host IPC is not configured", Success: true, Severity: conf.SeverityDanger, Category: "Security"},
+						"hostPIDSet": {ID: "hostPIDSet", Message: "Host PID is not configured", Success: true, Severity: conf.SeverityIgnore, Category: "Security"},
+					},
+					ContainerResults: []ContainerResult{
+						{
+							Name: "nginx",
+							Results: ResultSet{
+								"runAsRootAllowed": {ID: "runAsRootAllowed", Message: "Should not be allowed to run as root", Success: false, Severity: conf.SeverityDanger, Category: "Security"},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:    "admin",
+				Kind:    "ClusterRole",
+				Results: ResultSet{},
+			},
+		},
+	}
+
+	output, err := auditData.GetJUnitOutput()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(output), xml.Header))
+
+	var report junitTestSuites
+	assert.NoError(t, xml.Unmarshal(output, &report))
+	assert.Equal(t, 4, report.Tests)
+	assert.Equal(t, 2, report.Failures)
+	assert.Equal(t, 1, report.Skipped)
+	if !assert.Len(t, report.Suites, 2) {
+		return
+	}
+
+	suite := report.Suites[0]
+	assert.Equal(t, "Deployment/default/web", suite.Name)
+	assert.Equal(t, "2022-01-01T00:00:00Z", suite.Timestamp)
+	if assert.Len(t, suite.TestCases, 4) {
+		assert.Equal(t, "deploymentMissingReplicas", suite.TestCases[0].Name)
+		assert.Equal(t, "Deployment/default/web", suite.TestCases[0].ClassName)
+		if assert.NotNil(t, suite.TestCases[0].Failure) {
+			assert.Equal(t, "warning", suite.TestCases[0].Failure.Type)
+			assert.Equal(t, "Only one replica is scheduled", suite.TestCases[0].Failure.Message)
+		}
+
+		assert.Equal(t, "hostIPCSet", suite.TestCases[1].Name)
+		assert.Equal(t, "Deployment/default/web/pod", suite.TestCases[1].ClassName)
+		assert.Nil(t, suite.TestCases[1].Failure)
+		assert.Nil(t, suite.TestCases[1].Skipped)
+
+		assert.Equal(t, "hostPIDSet", suite.TestCases[2].Name)
+		assert.NotNil(t, suite.TestCases[2].Skipped)
+
+		assert.Equal(t, "Deployment/default/web/container/nginx", suite.TestCases[3].ClassName)
+		if assert.NotNil(t, suite.TestCases[3].Failure) {
+			assert.Equal(t, "danger", suite.TestCases[3].Failure.Type)
+		}
+	}
+	assert.Equal(t, "ClusterRole/admin", report.Suites[1].Name)
+	assert.Equal(t, 0, report.Suites[1].Tests)
+}
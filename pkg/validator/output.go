@@ -27,13 +27,14 @@ import (
 
 const (
 	// PolarisOutputVersion is the version of the current output structure
-	PolarisOutputVersion = "1.0"
+	PolarisOutputVersion = "1.1"
 )
 
 var (
 	successMessage = "🎉 Success"
 	dangerMessage  = "❌ Danger"
 	warningMessage = "😬 Warning"
+	exemptMessage  = "🙈 Exempted"
 )
 
 var (
@@ -51,6 +52,15 @@ type AuditData struct {
 	ClusterInfo          ClusterInfo
 	Results              []Result
 	Score                uint
+	// FixedBaselineEntries lists the baseline entries that no longer fail, if a baseline was applied
+	FixedBaselineEntries []BaselineEntry `json:",omitempty"`
+	// ExpiringExemptions lists the config exemptions that have expired or will expire soon
+	ExpiringExemptions []ExpiringExemption `json:",omitempty"`
+	// StaleExemptions lists the exemptions that didn't keep any failing check from running, if the
+	// configuration sets reportStaleExemptions
+	StaleExemptions []ExemptionUsage `json:",omitempty"`
+	// ConfigHash identifies the configuration the audit ran with, as printed by polaris config print
+	ConfigHash string `json:",omitempty"`
 }
 
 // FilterResultsBySeverityLevel includes results according to the provided severity level:
@@ -100,15 +110,88 @@ type ClusterInfo struct {
 	Controllers int
 }
 
+// ResultDetail describes a single JSON schema error behind a failed check
+type ResultDetail struct {
+	// PropertyPath is a JSON pointer to the offending field within the resource
+	PropertyPath string
+	InvalidValue any `json:",omitempty"`
+	Message      string
+}
+
+// String returns a human-readable description of the detail
+func (d ResultDetail) String() string {
+	switch d.InvalidValue.(type) {
+	case string, bool, int, int64, float64:
+		return fmt.Sprintf("%s: %s (got %v)", d.PropertyPath, d.Message, d.InvalidValue)
+	}
+	return fmt.Sprintf("%s: %s", d.PropertyPath, d.Message)
+}
+
 // ResultMessage is the result of a given check
 type ResultMessage struct {
 	ID        string
 	Message   string
-	Details   []string
+	Details   []ResultDetail `json:",omitempty"`
 	Success   bool
 	Severity  config.Severity
 	Category  string
 	Mutations []config.Mutation
+	// Line is the line of the result's source file that the failure was found on
+	Line int `json:",omitempty"`
+	// Exempted is set if an exemption kept the check from running. Exempted results are only included
+	// if the configuration sets includeExempted, or reportStaleExemptions, which needs them to find the
+	// exemptions that are no longer needed. Audits remove them again unless includeExempted is set.
+	Exempted  bool             `json:",omitempty"`
+	Exemption *ResultExemption `json:",omitempty"`
+}
+
+// ExemptionSource is the kind of exemption that kept a check from running
+type ExemptionSource string
+
+const (
+	// ExemptionSourceConfig is an exemption from the configuration's exemptions list
+	ExemptionSourceConfig ExemptionSource = "config"
+	// ExemptionSourceAnnotation is an exemption annotation on the resource
+	ExemptionSourceAnnotation ExemptionSource = "annotation"
+)
+
+// ResultExemption describes the exemption that kept a check from running
+type ResultExemption struct {
+	Source ExemptionSource
+	// Annotation is the annotation key, for annotation exemptions
+	Annotation string `json:",omitempty"`
+	// ConfigIndex is the position of the exemption in the config's exemptions list, for config exemptions
+	ConfigIndex int    `json:",omitempty"`
+	Owner       string `json:",omitempty"`
+	Reason      string `json:",omitempty"`
+	Ticket      string `json:",omitempty"`
+	Expires     string `json:",omitempty"`
+	// Suppressed is set if the check would have failed without the exemption. It's only known if the
+	// configuration sets reportStaleExemptions.
+	Suppressed bool `json:",omitempty"`
+}
+
+func newConfigResultExemption(idx int, exemption config.Exemption) *ResultExemption {
+	return &ResultExemption{
+		Source:      ExemptionSourceConfig,
+		ConfigIndex: idx,
+		Owner:       exemption.Owner,
+		Reason:      exemption.Reason,
+		Ticket:      exemption.Ticket,
+		Expires:     exemption.Expires,
+	}
+}
+
+// String returns a human-readable description of the exemption
+func (e ResultExemption) String() string {
+	if e.Source == ExemptionSourceAnnotation {
+		return "exempted by annotation " + e.Annotation
+	}
+	str := fmt.Sprintf("exempted by config exemption %d", e.ConfigIndex)
+	if e.Reason != "" {
+		str += ": " + e.Reason
+	}
+	return str
 }
 
 // ResultSet contiains the results for a set of checks
@@ -155,6 +238,11 @@ type Result struct {
 	Results     ResultSet
 	PodResult   *PodResult
 	CreatedTime time.Time
+	SourceFile  string `json:",omitempty"`
+	SourceLine  int    `json:",omitempty"`
+	// Exemptions lists the checks that config exemptions kept from running, whether or not their
+	// results are included
+	Exemptions []ExemptedCheck `json:",omitempty"`
 }
 
 func (res Result) removeSuccessfulResults() Result {
@@ -258,6 +346,27 @@ func (res AuditData) GetPrettyOutput(useColor bool) string {
 	for _, result := range res.Results {
 		str.WriteString(result.GetPrettyOutput() + "\n")
 	}
+	if len(res.FixedBaselineEntries) > 0 {
+		str.WriteString(titleColor.Sprint("Fixed since baseline\n"))
+		for _, entry := range res.FixedBaselineEntries {
+			str.WriteString(color.GreenString(fmt.Sprintf("    %s\n", entry)))
+		}
+		str.WriteString("\n")
+	}
+	if len(res.StaleExemptions) > 0 {
+		str.WriteString(titleColor.Sprint("Stale exemptions\n"))
+		for _, usage := range res.StaleExemptions {
+			str.WriteString(color.YellowString(fmt.Sprintf("    %s\n", usage)))
+		}
+		str.WriteString("\n")
+	}
+	if len(res.ExpiringExemptions) > 0 {
+		str.WriteString(titleColor.Sprint("Expiring exemptions\n"))
+		for _, exemption := range res.ExpiringExemptions {
+			str.WriteString(color.YellowString(fmt.Sprintf("    %s\n", exemption)))
+		}
+		str.WriteString("\n")
+	}
 	color.NoColor = false
 	return str.String()
 }
@@ -273,6 +382,25 @@ func (res Result) GetPrettyOutput() string {
 	if res.PodResult != nil {
 		str += res.PodResult.GetPrettyOutput()
 	}
+	// Exempted checks whose results were kept have already been listed along with them
+	listed := map[string]bool{}
+	res.forEachResultSet(func(containerName string, resultSet ResultSet) {
+		for checkID, msg := range resultSet {
+			if msg.Exempted {
+				listed[containerName+"/"+checkID] = true
+			}
+		}
+	})
+	for _, exemption := range res.Exemptions {
+		if listed[exemption.Container+"/"+exemption.ID] {
+			continue
+		}
+		str += "    " + checkColor.Sprint(exemption.ID)
+		if exemption.Container != "" {
+			str += " in container " + exemption.Container
+		}
+		str += " " + exemption.String() + "\n"
+	}
 	return str
 }
 
@@ -301,7 +429,9 @@ func (res ResultSet) GetPrettyOutput() string {
 	var str strings.Builder
 	for _, msg := range res {
 		status := color.GreenString(successMessage)
-		if !msg.Success {
+		if msg.Exempted {
+			status = color.BlueString(exemptMessage)
+		} else if !msg.Success {
 			if msg.Severity == config.SeverityWarning {
 				status = color.YellowString(warningMessage)
 			} else {
@@ -313,6 +443,12 @@ func (res ResultSet) GetPrettyOutput() string {
 		}
 		str.WriteString(fmt.Sprintf("%s%s %s\n", indent, checkColor.Sprint(fillString(msg.ID, minIDLength-len(indent))), status))
 		str.WriteString(fmt.Sprintf("%s    %s - %s\n", indent, msg.Category, msg.Message))
+		for _, detail := range msg.Details {
+			str.WriteString(fmt.Sprintf("%s      %s\n", indent, detail))
+		}
+		if msg.Exemption != nil {
+			str.WriteString(fmt.Sprintf("%s      %s\n", indent, msg.Exemption))
+		}
 	}
 	return str.String()
 }
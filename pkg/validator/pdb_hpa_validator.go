@@ -1,12 +1,12 @@
 package validator
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/fairwindsops/polaris/pkg/kube"
-	"github.com/qri-io/jsonschema"
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
@@ -16,71 +16,74 @@ import (
 )
 
 func init() {
-	registerCustomChecks("pdbMinAvailableGreaterThanHPAMinReplicas", pdbMinAvailableGreaterThanHPAMinReplicas)
+	RegisterCheck("pdbMinAvailableGreaterThanHPAMinReplicas", pdbMinAvailableGreaterThanHPAMinReplicas)
 }
 
-func pdbMinAvailableGreaterThanHPAMinReplicas(test schemaTestCase) (bool, []jsonschema.KeyError, error) {
+func pdbMinAvailableGreaterThanHPAMinReplicas(ctx context.Context, test CheckInput) (CheckOutcome, error) {
+	passes := CheckOutcome{Passes: true}
 	if test.ResourceProvider == nil {
-		return true, nil, nil
+		return passes, nil
 	}
 
 	deployment := &appsv1.Deployment{}
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(test.Resource.Resource.Object, deployment)
 	if err != nil {
 		logrus.Warnf("error converting unstructured to Deployment: %v", err)
-		return true, nil, nil
+		return passes, nil
 	}
 
 	attachedPDB, err := hasPDBAttached(*deployment, test.ResourceProvider.Resources["policy/PodDisruptionBudget"])
 	if err != nil {
 		logrus.Warnf("error getting PodDisruptionBudget: %v", err)
-		return true, nil, nil
+		return passes, nil
 	}
 
 	attachedHPA, err := hasHPAAttached(*deployment, test.ResourceProvider.Resources["autoscaling/HorizontalPodAutoscaler"])
 	if err != nil {
 		logrus.Warnf("error getting HorizontalPodAutoscaler: %v", err)
-		return true, nil, nil
+		return passes, nil
 	}
 
 	if attachedPDB != nil && attachedHPA != nil {
 		logrus.Debugf("both PDB and HPA are attached to deployment %s", deployment.Name)
 
 		if attachedPDB.Spec.MinAvailable == nil {
-			return true, nil, nil
+			return passes, nil
 		}
 
 		pdbMinAvailable, isPercent, err := getIntOrPercentValueSafely(attachedPDB.Spec.MinAvailable)
 		if err != nil {
 			logrus.Warnf("error getting getIntOrPercentValueSafely: %v", err)
-			return true, nil, nil
+			return passes, nil
 		}
 
 		if isPercent {
 			// if the value is a percentage, we need to calculate the actual value
 			if attachedHPA.Spec.MinReplicas == nil {
-				return true, nil, nil
+				return passes, nil
 			}
 
 			pdbMinAvailable, err = intstr.GetScaledValueFromIntOrPercent(attachedPDB.Spec.MinAvailable, int(*attachedHPA.Spec.MinReplicas), true)
 			if err != nil {
 				logrus.Warnf("error getting minAvailable value from PodDisruptionBudget: %v", err)
-				return true, nil, nil
+				return passes, nil
 			}
 		}
 
 		if attachedHPA.Spec.MinReplicas != nil && pdbMinAvailable > int(*attachedHPA.Spec.MinReplicas) {
-			return false, []jsonschema.KeyError{
-				{
-					PropertyPath: "spec.minAvailable",
-					InvalidValue: pdbMinAvailable,
-					Message:      fmt.Sprintf("The minAvailable value in the PodDisruptionBudget(%s) is %d, which is greater than the minReplicas value in the HorizontalPodAutoscaler(%s) (%d)", attachedPDB.Name, pdbMinAvailable, attachedHPA.Name, *attachedHPA.Spec.MinReplicas),
+			return CheckOutcome{
+				Details: []ResultDetail{
+					{
+						PropertyPath: "spec.minAvailable",
+						InvalidValue: pdbMinAvailable,
+						Message:      fmt.Sprintf("The minAvailable value in the PodDisruptionBudget(%s) is %d, which is greater than the minReplicas value in the HorizontalPodAutoscaler(%s) (%d)", attachedPDB.Name, pdbMinAvailable, attachedHPA.Name, *attachedHPA.Spec.MinReplicas),
+					},
 				},
 			}, nil
 		}
 	}
 
-	return true, nil, nil
+	return passes, nil
 }
 
 func hasPDBAttached(deployment appsv1.Deployment, pdbs []kube.GenericResource) (*policyv1.PodDisruptionBudget, error) {
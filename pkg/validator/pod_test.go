@@ -107,13 +107,34 @@ func TestInvalidIPCPod(t *testing.T) {
 		Warnings:  uint(3),
 		Dangers:   uint(1),
 	}
+	hostPathValue := map[string]any{"path": "/var/run/docker.sock"}
 	expectedResults := ResultSet{
-		"hostIPCSet":     {ID: "hostIPCSet", Message: "Host IPC should not be configured", Success: false, Severity: "danger", Category: "Security"},
+		"hostIPCSet": {ID: "hostIPCSet", Message: "Host IPC should not be configured", Success: false, Severity: "danger", Category: "Security", Details: []ResultDetail{{
+			PropertyPath: "/spec/hostIPC",
+			InvalidValue: true,
+			Message:      "result was valid, ('not') expected invalid",
+		}}},
 		"hostNetworkSet": {ID: "hostNetworkSet", Message: "Host network is not configured", Success: true, Severity: "warning", Category: "Security"},
 		"hostPIDSet":     {ID: "hostPIDSet", Message: "Host PID is not configured", Success: true, Severity: "danger", Category: "Security"},
-		"hostPathSet":    {ID: "hostPathSet", Message: "HostPath volumes must be forbidden", Success: false, Severity: "warning", Category: "Security"},
-		"procMount":      {ID: "procMount", Message: "Proc mount must not be changed from the default", Success: false, Severity: "warning", Category: "Security"},
-		"hostProcess":    {ID: "hostProcess", Message: "Privileged access to the host is disallowed", Success: false, Severity: "warning", Category: "Security"},
+		"hostPathSet": {ID: "hostPathSet", Message: "HostPath volumes must be forbidden", Success: false, Severity: "warning", Category: "Security", Details: []ResultDetail{{
+			PropertyPath: "/spec/volumes/0/hostPath",
+			InvalidValue: hostPathValue,
+			Message:      "type should be string, got object",
+		}, {
+			PropertyPath: "/spec/volumes/0/hostPath",
+			InvalidValue: hostPathValue,
+			Message:      `must equal ""`,
+		}}},
+		"procMount": {ID: "procMount", Message: "Proc mount must not be changed from the default", Success: false, Severity: "warning", Category: "Security", Details: []ResultDetail{{
+			PropertyPath: "/spec/containers/0/securityContext/procMount",
+			InvalidValue: "Unmasked",
+			Message:      `must equal "Default"`,
+		}}},
+		"hostProcess": {ID: "hostProcess", Message: "Privileged access to the host is disallowed", Success: false, Severity: "warning", Category: "Security", Details: []ResultDetail{{
+			PropertyPath: "/spec/containers/0/securityContext/windowsOptions/hostProcess",
+			InvalidValue: true,
+			Message:      "must equal false",
+		}}},
 	}
 
 	actualPodResult, err := applyControllerSchemaChecks(context.Background(), &c, nil, workload)
@@ -147,9 +168,13 @@ func TestInvalidNetworkPod(t *testing.T) {
 	}
 
 	expectedResults := ResultSet{
-		"hostNetworkSet": {ID: "hostNetworkSet", Message: "Host network should not be configured", Success: false, Severity: "warning", Category: "Security"},
-		"hostIPCSet":     {ID: "hostIPCSet", Message: "Host IPC is not configured", Success: true, Severity: "danger", Category: "Security"},
-		"hostPIDSet":     {ID: "hostPIDSet", Message: "Host PID is not configured", Success: true, Severity: "danger", Category: "Security"},
+		"hostNetworkSet": {ID: "hostNetworkSet", Message: "Host network should not be configured", Success: false, Severity: "warning", Category: "Security", Details: []ResultDetail{{
+			PropertyPath: "/spec/hostNetwork",
+			InvalidValue: true,
+			Message:      "result was valid, ('not') expected invalid",
+		}}},
+		"hostIPCSet": {ID: "hostIPCSet", Message: "Host IPC is not configured", Success: true, Severity: "danger", Category: "Security"},
+		"hostPIDSet": {ID: "hostPIDSet", Message: "Host PID is not configured", Success: true, Severity: "danger", Category: "Security"},
 	}
 
 	actualPodResult, err := applyControllerSchemaChecks(context.Background(), &c, nil, workload)
@@ -183,7 +208,11 @@ func TestInvalidPIDPod(t *testing.T) {
 	}
 
 	expectedResults := ResultSet{
-		"hostPIDSet":     {ID: "hostPIDSet", Message: "Host PID should not be configured", Success: false, Severity: "danger", Category: "Security"},
+		"hostPIDSet": {ID: "hostPIDSet", Message: "Host PID should not be configured", Success: false, Severity: "danger", Category: "Security", Details: []ResultDetail{{
+			PropertyPath: "/spec/hostPID",
+			InvalidValue: true,
+			Message:      "result was valid, ('not') expected invalid",
+		}}},
 		"hostIPCSet":     {ID: "hostIPCSet", Message: "Host IPC is not configured", Success: true, Severity: "danger", Category: "Security"},
 		"hostNetworkSet": {ID: "hostNetworkSet", Message: "Host network is not configured", Success: true, Severity: "warning", Category: "Security"},
 	}
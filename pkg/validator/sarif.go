@@ -0,0 +1,259 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fairwindsops/polaris/pkg/config"
+)
+
+const (
+	sarifVersion   = "2.1.0"
+	sarifSchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName  = "Polaris"
+	sarifToolURI   = "https://github.com/FairwindsOps/polaris"
+	checksDocsURI  = "https://polaris.docs.fairwinds.com/checks/"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                  `json:"id"`
+	Name                 string                  `json:"name"`
+	ShortDescription     sarifMessage            `json:"shortDescription"`
+	FullDescription      sarifMessage            `json:"fullDescription"`
+	MessageStrings       map[string]sarifMessage `json:"messageStrings,omitempty"`
+	HelpURI              string                  `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration      `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties     `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperties struct {
+	Category string   `json:"category,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifBuilder accumulates rules and results while walking an AuditData
+type sarifBuilder struct {
+	conf      config.Configuration
+	rules     []sarifRule
+	ruleIndex map[string]int
+	results   []sarifResult
+}
+
+// GetSARIFOutput returns the failed checks of the audit as a SARIF 2.1.0 log
+func (res AuditData) GetSARIFOutput(conf config.Configuration, toolVersion string) ([]byte, error) {
+	builder := sarifBuilder{
+		conf:      conf,
+		rules:     []sarifRule{},
+		ruleIndex: map[string]int{},
+		results:   []sarifResult{},
+	}
+	for _, checkID := range getSortedKeys(conf.Checks) {
+		builder.addRule(checkID)
+	}
+	for _, result := range res.Results {
+		builder.addResultSet(result, "", result.Results)
+		if result.PodResult == nil {
+			continue
+		}
+		builder.addResultSet(result, "", result.PodResult.Results)
+		for _, containerResult := range result.PodResult.ContainerResults {
+			builder.addResultSet(result, containerResult.Name, containerResult.Results)
+		}
+	}
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchemaURI,
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:           sarifToolName,
+					Version:        toolVersion,
+					InformationURI: sarifToolURI,
+					Rules:          builder.rules,
+				},
+			},
+			Results: builder.results,
+		}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
+
+func (b *sarifBuilder) addRule(checkID string) int {
+	if idx, ok := b.ruleIndex[checkID]; ok {
+		return idx
+	}
+	rule := sarifRule{
+		ID:                   checkID,
+		Name:                 checkID,
+		ShortDescription:     sarifMessage{Text: checkID},
+		FullDescription:      sarifMessage{Text: checkID},
+		DefaultConfiguration: sarifConfiguration{Level: getSARIFLevel(b.conf.Checks[checkID])},
+		Properties: sarifRuleProperties{
+			Severity: string(b.conf.Checks[checkID]),
+		},
+	}
+	check, ok := b.conf.CustomChecks[checkID]
+	if !ok {
+		check, ok = config.BuiltInChecks[checkID]
+	}
+	if ok {
+		rule.ShortDescription = sarifMessage{Text: check.FailureMessage}
+		rule.FullDescription = sarifMessage{Text: check.FailureMessage}
+		rule.MessageStrings = map[string]sarifMessage{
+			"success": {Text: check.SuccessMessage},
+			"failure": {Text: check.FailureMessage},
+		}
+		if check.Category != "" {
+			rule.HelpURI = checksDocsURI + strings.ToLower(check.Category)
+			rule.Properties.Category = check.Category
+			rule.Properties.Tags = []string{check.Category}
+		}
+	}
+	b.rules = append(b.rules, rule)
+	b.ruleIndex[checkID] = len(b.rules) - 1
+	return len(b.rules) - 1
+}
+
+func (b *sarifBuilder) addResultSet(result Result, containerName string, resultSet ResultSet) {
+	checkIDs := make([]string, 0, len(resultSet))
+	for checkID := range resultSet {
+		checkIDs = append(checkIDs, checkID)
+	}
+	sort.Strings(checkIDs)
+	for _, checkID := range checkIDs {
+		msg := resultSet[checkID]
+		if msg.Success {
+			continue
+		}
+		b.results = append(b.results, sarifResult{
+			RuleID:    msg.ID,
+			RuleIndex: b.addRule(msg.ID),
+			Level:     getSARIFLevel(msg.Severity),
+			Message:   sarifMessage{Text: msg.Message},
+			Locations: []sarifLocation{getSARIFLocation(result, containerName, msg.Line)},
+		})
+	}
+}
+
+func getSARIFLocation(result Result, containerName string, line int) sarifLocation {
+	nameParts := []string{}
+	if result.Namespace != "" {
+		nameParts = append(nameParts, result.Namespace)
+	}
+	nameParts = append(nameParts, result.Kind, result.Name)
+	logical := sarifLogicalLocation{
+		Name:               result.Name,
+		FullyQualifiedName: strings.Join(nameParts, "/"),
+		Kind:               "resource",
+	}
+	if containerName != "" {
+		logical.Name = containerName
+		logical.FullyQualifiedName += "/" + containerName
+		logical.Kind = "container"
+	}
+	location := sarifLocation{
+		LogicalLocations: []sarifLogicalLocation{logical},
+	}
+	if result.SourceFile != "" {
+		location.PhysicalLocation = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(result.SourceFile)},
+		}
+		if line == 0 {
+			line = result.SourceLine
+		}
+		if line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+		}
+	}
+	return location
+}
+
+func getSARIFLevel(severity config.Severity) string {
+	switch severity {
+	case config.SeverityDanger:
+		return "error"
+	case config.SeverityWarning:
+		return "warning"
+	default:
+		return "none"
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"encoding/json"
+	"testing"
+
+	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func getSARIFTestAudit() AuditData {
+	return AuditData{
+		PolarisOutputVersion: PolarisOutputVersion,
+		SourceType:           "Path",
+		SourceName:           "./deploy",
+		Results: []Result{
+			{
+				Name:       "web",
+				Namespace:  "default",
+				Kind:       "Deployment",
+				SourceFile: "deploy/web.yaml",
+				SourceLine: 3,
+				Results: ResultSet{
+					"deploymentMissingReplicas": {ID: "deploymentMissingReplicas", Message: "Only one replica is scheduled", Success: false, Severity: conf.SeverityWarning, Category: "Reliability"},
+				},
+				PodResult: &PodResult{
+					Results: ResultSet{
+						"hostIPCSet": {ID: "hostIPCSet", Message: "Host IPC is not configured", Success: true, Severity: conf.SeverityDanger, Category: "Security"},
+					},
+					ContainerResults: []ContainerResult{
+						{
+							Name: "nginx",
+							Results: ResultSet{
+								"runAsRootAllowed": {ID: "runAsRootAllowed", Message: "Should not be allowed to run as root", Success: false, Severity: conf.SeverityDanger, Category: "Security", Line: 24},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetSARIFOutput(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"deploymentMissingReplicas": conf.SeverityWarning,
+			"hostIPCSet":                conf.SeverityDanger,
+			"runAsRootAllowed":          conf.SeverityDanger,
+		},
+	}
+	output, err := getSARIFTestAudit().GetSARIFOutput(c, "1.2.3")
+	assert.NoError(t, err)
+
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal(output, &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	if !assert.Len(t, log.Runs, 1) {
+		return
+	}
+	run := log.Runs[0]
+	assert.Equal(t, "Polaris", run.Tool.Driver.Name)
+	assert.Equal(t, "1.2.3", run.Tool.Driver.Version)
+
+	assert.Len(t, run.Tool.Driver.Rules, 3)
+	rule := run.Tool.Driver.Rules[2]
+	assert.Equal(t, "runAsRootAllowed", rule.ID)
+	assert.Equal(t, "error", rule.DefaultConfiguration.Level)
+	assert.Equal(t, "Security", rule.Properties.Category)
+	assert.Equal(t, "danger", rule.Properties.Severity)
+	assert.Equal(t, "https://polaris.docs.fairwinds.com/checks/security", rule.HelpURI)
+	assert.Equal(t, conf.BuiltInChecks["runAsRootAllowed"].FailureMessage, rule.MessageStrings["failure"].Text)
+
+	if !assert.Len(t, run.Results, 2) {
+		return
+	}
+	assert.Equal(t, "deploymentMissingReplicas", run.Results[0].RuleID)
+	assert.Equal(t, 0, run.Results[0].RuleIndex)
+	assert.Equal(t, "warning", run.Results[0].Level)
+	assert.Equal(t, "default/Deployment/web", run.Results[0].Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, 3, run.Results[0].Locations[0].PhysicalLocation.Region.StartLine, "results without a line should point at the resource")
+
+	containerResult := run.Results[1]
+	assert.Equal(t, "runAsRootAllowed", containerResult.RuleID)
+	assert.Equal(t, 2, containerResult.RuleIndex)
+	assert.Equal(t, "error", containerResult.Level)
+	assert.Equal(t, "Should not be allowed to run as root", containerResult.Message.Text)
+	location := containerResult.Locations[0]
+	assert.Equal(t, "default/Deployment/web/nginx", location.LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, "container", location.LogicalLocations[0].Kind)
+	if assert.NotNil(t, location.PhysicalLocation) {
+		assert.Equal(t, "deploy/web.yaml", location.PhysicalLocation.ArtifactLocation.URI)
+		assert.Equal(t, 24, location.PhysicalLocation.Region.StartLine)
+	}
+}
+
+func TestGetSARIFOutputUnknownRule(t *testing.T) {
+	output, err := getSARIFTestAudit().GetSARIFOutput(conf.Configuration{}, "")
+	assert.NoError(t, err)
+
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal(output, &log))
+	assert.Len(t, log.Runs[0].Results, 2)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, 2, "rules should be added for results without a configured severity")
+	assert.Equal(t, "deploymentMissingReplicas", log.Runs[0].Tool.Driver.Rules[0].ID)
+}
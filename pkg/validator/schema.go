@@ -16,12 +16,14 @@ package validator
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/qri-io/jsonschema"
 	"github.com/sirupsen/logrus"
@@ -29,6 +31,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/fairwindsops/polaris/pkg/config"
 	"github.com/fairwindsops/polaris/pkg/kube"
@@ -42,6 +45,34 @@ type schemaTestCase struct {
 	ResourceProvider *kube.ResourceProvider
 }
 
+// exemptionSubject describes the test case for matching config exemptions
+func (s schemaTestCase) exemptionSubject(conf *config.Configuration) config.ExemptionSubject {
+	subject := config.ExemptionSubject{
+		Kind:   s.Resource.Kind,
+		Object: s.Resource.ObjectMeta,
+	}
+	if s.Container != nil {
+		subject.ContainerName = s.Container.Name
+	}
+	if conf.UsesNamespaceSelectors() {
+		subject.Namespace = getNamespace(s.ResourceProvider, s.Resource.ObjectMeta.GetNamespace())
+	}
+	return subject
+}
+
+// getNamespace returns the namespace with the given name, or nil if it wasn't loaded
+func getNamespace(resourceProvider *kube.ResourceProvider, name string) metaV1.Object {
+	if resourceProvider == nil || name == "" {
+		return nil
+	}
+	for idx := range resourceProvider.Namespaces {
+		if resourceProvider.Namespaces[idx].Name == name {
+			return &resourceProvider.Namespaces[idx]
+		}
+	}
+	return nil
+}
+
 // ShortString supplies some fields of a schemaTestCase suitable for brief
 // output.
 func (s schemaTestCase) ShortString() string {
@@ -61,49 +92,65 @@ func (s schemaTestCase) ShortString() string {
 	return msg.String()
 }
 
-func resolveCheck(conf *config.Configuration, checkID string, test schemaTestCase) (*config.SchemaCheck, error) {
-	if !conf.DisallowExemptions &&
-		!conf.DisallowAnnotationExemptions &&
-		hasExemptionAnnotation(test.Resource.ObjectMeta, checkID) {
-		return nil, nil
+// resolveCheck returns the check to run for a test case, or nil if it doesn't apply. If an exemption
+// applies, the check is returned along with the exemption, unless it's an annotation exemption and
+// exempted checks aren't kept. The check only needs to run if conf.ReportStaleExemptions is set, to find
+// out whether the exemption is still needed.
+func resolveCheck(conf *config.Configuration, checkID string, test schemaTestCase, subject config.ExemptionSubject) (*config.SchemaCheck, *ResultExemption, error) {
+	keepExempted := conf.IncludeExempted || conf.ReportStaleExemptions
+	annotation := ""
+	if !conf.DisallowExemptions && !conf.DisallowAnnotationExemptions {
+		annotation = getExemptionAnnotation(test.Resource.ObjectMeta, checkID)
+	}
+	if annotation != "" && !keepExempted {
+		return nil, nil, nil
 	}
 	check, ok := conf.CustomChecks[checkID]
 	if !ok {
 		check, ok = config.BuiltInChecks[checkID]
 	}
 	if !ok {
-		return nil, fmt.Errorf("Check %s not found", checkID)
+		if annotation != "" {
+			// The check was always skipped for annotated resources, so an exempted check with no definition
+			// is skipped rather than failing the audit
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("Check %s not found", checkID)
 	}
 
-	containerName := ""
-	if test.Container != nil {
-		containerName = test.Container.Name
+	if !check.IsActionable(test.Target, test.Resource.Kind, test.IsInitContainer) {
+		return nil, nil, nil
 	}
-	if !conf.IsActionable(check.ID, test.Resource.ObjectMeta, containerName) {
-		return nil, nil
+	var exemption *ResultExemption
+	if annotation != "" {
+		exemption = &ResultExemption{Source: ExemptionSourceAnnotation, Annotation: annotation}
+	} else if idx, configExemption := conf.GetExemption(check.ID, subject); configExemption != nil {
+		// Config exemptions are always recorded, so the result can list them in Exemptions
+		exemption = newConfigResultExemption(idx, *configExemption)
 	}
-	if !check.IsActionable(test.Target, test.Resource.Kind, test.IsInitContainer) {
-		return nil, nil
+	if exemption != nil && !conf.ReportStaleExemptions {
+		return &check, exemption, nil
 	}
 	templateInput, err := getTemplateInput(test)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	checkPtr, err := check.TemplateForResource(templateInput)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return checkPtr, nil
+	return checkPtr, exemption, nil
 }
 
-// getTemplateInput augments a schemaTestCase.Resource.Resource.Object with
+// getTemplateInput augments a copy of schemaTestCase.Resource.Resource.Object with
 // Polaris built-in variables. The result can be used as input for
 // CheckSchema.TemplateForResource().
 func getTemplateInput(test schemaTestCase) (map[string]any, error) {
-	templateInput := test.Resource.Resource.Object
-	if templateInput == nil {
+	if test.Resource.Resource.Object == nil {
 		return nil, nil
 	}
+	// Copy the object, since other checks may be reading it concurrently
+	templateInput := maps.Clone(test.Resource.Resource.Object)
 	if test.Target == config.TargetPodSpec || test.Target == config.TargetContainer {
 		podSpecMap, err := kube.SerializePodSpec(test.Resource.PodSpec)
 		if err != nil {
@@ -135,18 +182,22 @@ func getTemplateInput(test schemaTestCase) (map[string]any, error) {
 	return templateInput, nil
 }
 
-func makeResult(conf *config.Configuration, check *config.SchemaCheck, passes bool, issues []jsonschema.KeyError) ResultMessage {
-	details := []string{}
-	for _, issue := range issues {
-		details = append(details, issue.Message)
-	}
+func makeResult(severity config.Severity, check *config.SchemaCheck, passes bool, issues []jsonschema.KeyError) ResultMessage {
 	result := ResultMessage{
 		ID:       check.ID,
-		Severity: conf.Checks[check.ID],
+		Severity: severity,
 		Category: check.Category,
 		Success:  passes,
-		// FIXME: need to fix the tests before adding this back
-		//Details: details,
+	}
+	if !passes && len(issues) > 0 {
+		result.Details = make([]ResultDetail, len(issues))
+		for i, issue := range issues {
+			result.Details[i] = ResultDetail{
+				PropertyPath: issue.PropertyPath,
+				InvalidValue: issue.InvalidValue,
+				Message:      issue.Message,
+			}
+		}
 	}
 	if passes {
 		result.Message = check.SuccessMessage
@@ -156,78 +207,161 @@ func makeResult(conf *config.Configuration, check *config.SchemaCheck, passes bo
 	return result
 }
 
+// makeExemptedResult records a check that was skipped because of an exemption. It counts as neither a
+// success nor a failure, but Success is set so that it never fails an audit.
+func makeExemptedResult(severity config.Severity, check *config.SchemaCheck, exemption *ResultExemption) ResultMessage {
+	return ResultMessage{
+		ID:        check.ID,
+		Message:   check.FailureMessage,
+		Success:   true,
+		Severity:  severity,
+		Category:  check.Category,
+		Exempted:  true,
+		Exemption: exemption,
+	}
+}
+
+// makeCELErrorResult records a CEL check whose expression errored on the resource, e.g. because a field
+// isn't set. It fails with the error as its message, so that one expression erroring on one resource
+// doesn't stop an audit. An exempted check stays exempted, and its exemption isn't reported as stale,
+// since it's unknown whether the check would have failed.
+func makeCELErrorResult(severity config.Severity, check *config.SchemaCheck, test schemaTestCase, exemption *ResultExemption, err error) *ResultMessage {
+	logrus.Warnf("error evaluating check %s for test-case %s: %v", check.ID, test.ShortString(), err)
+	if exemption != nil {
+		exemption.Suppressed = true
+		result := makeExemptedResult(severity, check, exemption)
+		return &result
+	}
+	result := makeResult(severity, check, false, nil)
+	result.Message = "Error evaluating check: " + err.Error()
+	return &result
+}
+
 const exemptionAnnotationKey = "polaris.fairwinds.com/exempt"
 const exemptionAnnotationPattern = "polaris.fairwinds.com/%s-exempt"
 
-func hasExemptionAnnotation(objMeta metaV1.Object, checkID string) bool {
+// getExemptionAnnotation returns the key of the annotation that exempts an object from a check, if any
+func getExemptionAnnotation(objMeta metaV1.Object, checkID string) string {
 	annot := objMeta.GetAnnotations()
 	val := annot[exemptionAnnotationKey]
 	if strings.ToLower(val) == "true" {
-		return true
+		return exemptionAnnotationKey
 	}
 	checkKey := fmt.Sprintf(exemptionAnnotationPattern, checkID)
 	val = annot[checkKey]
 	if strings.ToLower(val) == "true" {
-		return true
+		return checkKey
 	}
-	return false
+	return ""
 }
 
-// ApplyAllSchemaChecksToResourceProvider applies all available checks to a ResourceProvider
+// ApplyAllSchemaChecksToResourceProvider applies all available checks to a ResourceProvider.
+// Results are sorted by kind, then in the order the ResourceProvider lists resources.
 func ApplyAllSchemaChecksToResourceProvider(ctx context.Context, conf *config.Configuration, resourceProvider *kube.ResourceProvider) ([]Result, error) {
-	results := []Result{}
 	if resourceProvider == nil {
 		return nil, errors.New("No resource provider set, cannot apply schema checks")
 	}
-	for _, resources := range resourceProvider.Resources {
-		kindResults, err := ApplyAllSchemaChecksToAllResources(ctx, conf, resourceProvider, resources)
-		if err != nil {
-			return results, err
-		}
-		results = append(results, kindResults...)
-	}
-	return results, nil
+	return ApplyAllSchemaChecksToAllResources(ctx, conf, resourceProvider, getSortedResources(resourceProvider))
+}
+
+// getSortedResources lists the resources in a ResourceProvider, sorted by kind
+func getSortedResources(resourceProvider *kube.ResourceProvider) []kube.GenericResource {
+	kinds := make([]string, 0, len(resourceProvider.Resources))
+	for kind := range resourceProvider.Resources {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	var resources []kube.GenericResource
+	for _, kind := range kinds {
+		resources = append(resources, resourceProvider.Resources[kind]...)
+	}
+	return resources
 }
 
-// ApplyAllSchemaChecksToAllResources applies available checks to a list of resources
+// ApplyAllSchemaChecksToAllResources applies available checks to a list of resources, using up to
+// conf.Parallelism workers. Results are in the same order as resources.
 func ApplyAllSchemaChecksToAllResources(ctx context.Context, conf *config.Configuration, resourceProvider *kube.ResourceProvider, resources []kube.GenericResource) ([]Result, error) {
+	allResults, err := applyAllSchemaChecksInParallel(ctx, conf, resourceProvider, resources)
 	results := []Result{}
-	for _, resource := range resources {
-		result, err := ApplyAllSchemaChecks(ctx, conf, resourceProvider, resource)
-		if err != nil {
-			return results, err
-		}
+	for _, result := range allResults {
 		if result.Kind != "" && result.Name != "" {
 			results = append(results, result)
 		}
 	}
+	return results, err
+}
+
+// applyAllSchemaChecksInParallel returns a result for each resource, at the same index. On error,
+// only the results for resources before the first one that failed are returned.
+func applyAllSchemaChecksInParallel(ctx context.Context, conf *config.Configuration, resourceProvider *kube.ResourceProvider, resources []kube.GenericResource) ([]Result, error) {
+	results := make([]Result, len(resources))
+	errs := make([]error, len(resources))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < getParallelism(conf, len(resources)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				results[idx], errs[idx] = ApplyAllSchemaChecks(ctx, conf, resourceProvider, resources[idx])
+			}
+		}()
+	}
+	for idx := range resources {
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
+
+	for idx, err := range errs {
+		if err != nil {
+			return results[:idx], err
+		}
+	}
 	return results, nil
 }
 
+// getParallelism returns how many resources to validate at once, defaulting to the number of CPUs
+func getParallelism(conf *config.Configuration, resourceCount int) int {
+	parallelism := conf.Parallelism
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	return max(1, min(parallelism, resourceCount))
+}
+
 // ApplyAllSchemaChecks applies available checks to a single resource
 func ApplyAllSchemaChecks(ctx context.Context, conf *config.Configuration, resourceProvider *kube.ResourceProvider, resource kube.GenericResource) (Result, error) {
+	var result Result
+	var err error
 	if resource.PodSpec == nil {
-		return applyNonControllerSchemaChecks(ctx, conf, resourceProvider, resource)
+		result, err = applyNonControllerSchemaChecks(ctx, conf, resourceProvider, resource)
+	} else {
+		result, err = applyControllerSchemaChecks(ctx, conf, resourceProvider, resource)
 	}
-	return applyControllerSchemaChecks(ctx, conf, resourceProvider, resource)
+	return result, err
 }
 
 func applyNonControllerSchemaChecks(ctx context.Context, conf *config.Configuration, resourceProvider *kube.ResourceProvider, resource kube.GenericResource) (Result, error) {
 	finalResult := Result{
-		Kind:      resource.Kind,
-		Name:      resource.ObjectMeta.GetName(),
-		Namespace: resource.ObjectMeta.GetNamespace(),
+		Kind:       resource.Kind,
+		Name:       resource.ObjectMeta.GetName(),
+		Namespace:  resource.ObjectMeta.GetNamespace(),
+		SourceFile: resource.SourceFile,
+		SourceLine: resource.SourceLine,
 	}
 	resultSet, err := applyTopLevelSchemaChecks(ctx, conf, resourceProvider, resource, false)
 	finalResult.Results = resultSet
-	return finalResult, err
+	return finalResult.recordExemptions(conf), err
 }
 
 func applyControllerSchemaChecks(ctx context.Context, conf *config.Configuration, resourceProvider *kube.ResourceProvider, resource kube.GenericResource) (Result, error) {
 	finalResult := Result{
-		Kind:      resource.Kind,
-		Name:      resource.ObjectMeta.GetName(),
-		Namespace: resource.ObjectMeta.GetNamespace(),
+		Kind:       resource.Kind,
+		Name:       resource.ObjectMeta.GetName(),
+		Namespace:  resource.ObjectMeta.GetNamespace(),
+		SourceFile: resource.SourceFile,
+		SourceLine: resource.SourceLine,
 	}
 	resultSet, err := applyTopLevelSchemaChecks(ctx, conf, resourceProvider, resource, true)
 	if err != nil {
@@ -279,7 +413,7 @@ func applyControllerSchemaChecks(ctx context.Context, conf *config.Configuration
 		podRes.ContainerResults = append(podRes.ContainerResults, cRes)
 	}
 
-	return finalResult, nil
+	return finalResult.recordExemptions(conf), nil
 }
 
 func applyTopLevelSchemaChecks(ctx context.Context, conf *config.Configuration, resources *kube.ResourceProvider, res kube.GenericResource, isController bool) (ResultSet, error) {
@@ -316,8 +450,9 @@ func applyContainerSchemaChecks(ctx context.Context, conf *config.Configuration,
 func applySchemaChecks(ctx context.Context, conf *config.Configuration, test schemaTestCase) (ResultSet, error) {
 	results := ResultSet{}
 	checkIDs := getSortedKeys(conf.Checks)
+	subject := test.exemptionSubject(conf)
 	for _, checkID := range checkIDs {
-		result, err := applySchemaCheck(ctx, conf, checkID, test)
+		result, err := applySchemaCheck(ctx, conf, checkID, test, subject)
 		if err != nil {
 			return results, err
 		}
@@ -328,24 +463,29 @@ func applySchemaChecks(ctx context.Context, conf *config.Configuration, test sch
 	return results, nil
 }
 
-func applySchemaCheck(ctx context.Context, conf *config.Configuration, checkID string, test schemaTestCase) (*ResultMessage, error) {
-	check, err := resolveCheck(conf, checkID, test)
+func applySchemaCheck(ctx context.Context, conf *config.Configuration, checkID string, test schemaTestCase, subject config.ExemptionSubject) (*ResultMessage, error) {
+	severity := conf.GetSeverity(checkID, subject)
+	if !severity.IsActionable() {
+		return nil, nil
+	}
+	check, exemption, err := resolveCheck(conf, checkID, test, subject)
 	if err != nil {
 		return nil, err
 	} else if check == nil {
 		return nil, nil
+	} else if exemption != nil && !conf.ReportStaleExemptions {
+		result := makeExemptedResult(severity, check, exemption)
+		return &result, nil
 	}
 	var passes bool
 	var issues []jsonschema.KeyError
 	var prefix string
-	emptyValidator := true
-	validatorBytes, err := json.Marshal(check.Validator)
+	// linePrefix and containerPath locate the validated object within the resource, for finding the line an issue is on
+	var linePrefix, containerPath string
+	emptyValidator, err := check.HasEmptyValidator()
 	if err != nil {
 		return nil, err
 	}
-	if string(validatorBytes) != "" && string(validatorBytes) != "null" && string(validatorBytes) != "{}" {
-		emptyValidator = false
-	}
 	if check.SchemaTarget != "" {
 		if check.SchemaTarget == config.TargetPodSpec && check.Target == config.TargetContainer {
 			podCopy := *test.Resource.PodSpec
@@ -369,6 +509,8 @@ func applySchemaCheck(ctx context.Context, conf *config.Configuration, checkID s
 					prefix += "/containers/" + strconv.Itoa(containerIndex)
 				}
 			}
+			linePrefix = getJSONSchemaPrefix(test.Resource.Kind)
+			containerPath = prefix
 			passes, issues, err = check.CheckPodSpec(ctx, &podCopy)
 		} else {
 			return nil, fmt.Errorf("Unknown combination of target (%s) and schema target (%s)", check.Target, check.SchemaTarget)
@@ -376,9 +518,11 @@ func applySchemaCheck(ctx context.Context, conf *config.Configuration, checkID s
 	} else if check.Target == config.TargetPodSpec {
 		passes, issues, err = check.CheckPodSpec(ctx, test.Resource.PodSpec)
 		prefix = getJSONSchemaPrefix(test.Resource.Kind)
+		linePrefix = prefix
 	} else if check.Target == config.TargetPodTemplate {
 		passes, issues, err = check.CheckPodTemplate(ctx, test.Resource.PodTemplate)
 		prefix = getJSONSchemaPrefix(test.Resource.Kind)
+		linePrefix = strings.TrimSuffix(prefix, "/spec")
 	} else if check.Target == config.TargetContainer {
 		containerIndex := -1
 		if !test.IsInitContainer {
@@ -398,11 +542,10 @@ func applySchemaCheck(ctx context.Context, conf *config.Configuration, checkID s
 				prefix += "/containers/" + strconv.Itoa(containerIndex)
 			}
 		}
+		linePrefix = prefix
 		passes, issues, err = check.CheckContainer(ctx, test.Container)
 	} else if !emptyValidator {
 		passes, issues, err = check.CheckObject(ctx, test.Resource.Resource.Object)
-	} else if validatorMapper[checkID] != nil {
-		passes, issues, err = validatorMapper[checkID](test)
 	} else {
 		passes, issues, err = true, []jsonschema.KeyError{}, nil
 	}
@@ -417,22 +560,59 @@ func applySchemaCheck(ctx context.Context, conf *config.Configuration, checkID s
 			logrus.Warnf("no ResourceProvider available, check %s will not work in this context (e.g. admission control)", checkID)
 			break
 		}
-		resources := test.ResourceProvider.Resources[groupkind]
-		namespace := test.Resource.ObjectMeta.GetNamespace()
-		if test.Resource.Kind == "Namespace" {
-			namespace = test.Resource.ObjectMeta.GetName()
-		}
-		resources = funk.Filter(resources, func(res kube.GenericResource) bool {
-			return res.ObjectMeta.GetNamespace() == "" || res.ObjectMeta.GetNamespace() == namespace
-		}).([]kube.GenericResource)
-		objects := funk.Map(resources, func(res kube.GenericResource) any {
-			return res.Resource.Object
-		}).([]any)
+		objects := getRelatedObjects(test, groupkind)
 		passes, err = check.CheckAdditionalObjects(ctx, groupkind, objects)
 		if err != nil {
 			return nil, err
 		}
 	}
+	var message string
+	if passes && (check.HasCEL() || check.HasRego() || check.HasWASM()) {
+		input, err := getCheckInput(check, test)
+		if err != nil {
+			return nil, err
+		}
+		if check.HasCEL() {
+			passes, message, err = check.CheckCEL(input)
+			if err != nil {
+				return makeCELErrorResult(severity, check, test, exemption, err), nil
+			}
+		}
+		if passes && check.HasRego() {
+			passes, message, err = check.CheckRego(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if passes && check.HasWASM() {
+			var wasmIssues []jsonschema.KeyError
+			passes, message, wasmIssues, err = check.CheckWASM(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, wasmIssues...)
+		}
+	}
+	if checkFunc := getCheckFunc(checkID); passes && checkFunc != nil {
+		outcome, err := checkFunc(ctx, CheckInput{
+			Resource:         test.Resource,
+			PodSpec:          test.Resource.PodSpec,
+			Container:        test.Container,
+			IsInitContainer:  test.IsInitContainer,
+			ResourceProvider: test.ResourceProvider,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", checkID, err)
+		}
+		passes, message = outcome.Passes, outcome.Message
+		for _, detail := range outcome.Details {
+			issues = append(issues, jsonschema.KeyError{
+				PropertyPath: detail.PropertyPath,
+				InvalidValue: detail.InvalidValue,
+				Message:      detail.Message,
+			})
+		}
+	}
 	if len(issues) > 0 {
 		issueMessages := make([]string, len(issues))
 		for i, issue := range issues {
@@ -443,7 +623,21 @@ func applySchemaCheck(ctx context.Context, conf *config.Configuration, checkID s
 		logrus.Debugf("there were no issues validating the schema for test-case %s", test.ShortString())
 
 	}
-	result := makeResult(conf, check, passes, issues)
+	for i := range issues {
+		issues[i].PropertyPath = getIssuePath(linePrefix, containerPath, issues[i].PropertyPath)
+	}
+	if exemption != nil {
+		exemption.Suppressed = !passes
+		result := makeExemptedResult(severity, check, exemption)
+		return &result, nil
+	}
+	result := makeResult(severity, check, passes, issues)
+	if !passes {
+		result.Line = getIssueLine(test.Resource, linePrefix, issues)
+	}
+	if message != "" {
+		result.Message = message
+	}
 	if funk.Contains(conf.Mutations, checkID) && len(check.Mutations) > 0 {
 		mutations := funk.Map(check.Mutations, func(mutation config.Mutation) config.Mutation {
 			mutationCopy := deepCopyMutation(mutation)
@@ -455,6 +649,86 @@ func applySchemaCheck(ctx context.Context, conf *config.Configuration, checkID s
 	return &result, nil
 }
 
+// getRelatedObjects returns the resources of a kind that are in the same namespace as the test case, or cluster-scoped
+func getRelatedObjects(test schemaTestCase, groupkind string) []any {
+	resources := test.ResourceProvider.Resources[groupkind]
+	namespace := test.Resource.ObjectMeta.GetNamespace()
+	if test.Resource.Kind == "Namespace" {
+		namespace = test.Resource.ObjectMeta.GetName()
+	}
+	resources = funk.Filter(resources, func(res kube.GenericResource) bool {
+		return res.ObjectMeta.GetNamespace() == "" || res.ObjectMeta.GetNamespace() == namespace
+	}).([]kube.GenericResource)
+	return funk.Map(resources, func(res kube.GenericResource) any {
+		return res.Resource.Object
+	}).([]any)
+}
+
+// getCheckInput builds the input that a check's CEL expression, Rego policy and WebAssembly module are evaluated with
+func getCheckInput(check *config.SchemaCheck, test schemaTestCase) (config.CheckInput, error) {
+	input := config.CheckInput{
+		Object:  test.Resource.Resource.Object,
+		Related: map[string][]any{},
+	}
+	var err error
+	if test.Resource.PodSpec != nil {
+		input.PodSpec, err = kube.SerializePodSpec(test.Resource.PodSpec)
+		if err != nil {
+			return input, err
+		}
+		input.PodTemplate, _ = test.Resource.PodTemplate.(map[string]any)
+	}
+	if test.Container != nil {
+		input.Container, err = kube.SerializeContainer(test.Container)
+		if err != nil {
+			return input, err
+		}
+	}
+	namespace := test.Resource.ObjectMeta.GetNamespace()
+	if namespace != "" {
+		input.NamespaceObject = map[string]any{"metadata": map[string]any{"name": namespace}}
+		if ns := getNamespace(test.ResourceProvider, namespace); ns != nil {
+			input.NamespaceObject, err = k8sRuntime.DefaultUnstructuredConverter.ToUnstructured(ns)
+			if err != nil {
+				return input, err
+			}
+		}
+	}
+	if len(check.RelatedResources) > 0 && test.ResourceProvider == nil {
+		logrus.Warnf("no ResourceProvider available, check %s can't look up related resources in this context (e.g. admission control)", check.ID)
+		return input, nil
+	}
+	for _, groupkind := range check.RelatedResources {
+		input.Related[groupkind] = getRelatedObjects(test, groupkind)
+	}
+	return input, nil
+}
+
+// getIssuePath converts the path of an issue, which is relative to the validated object,
+// into a JSON pointer relative to the whole resource
+func getIssuePath(linePrefix, containerPath, path string) string {
+	path = strings.TrimSuffix(path, "/")
+	if containerPath != "" {
+		// the pod spec was validated with only the container under test, at index 0
+		if path == "/containers/0" || strings.HasPrefix(path, "/containers/0/") {
+			return containerPath + strings.TrimPrefix(path, "/containers/0")
+		}
+	}
+	if linePrefix+path == "" {
+		return "/"
+	}
+	return linePrefix + path
+}
+
+// getIssueLine finds the line of the resource's source file that the first issue points at,
+// falling back to the validated object itself if there are no issues
+func getIssueLine(resource kube.GenericResource, linePrefix string, issues []jsonschema.KeyError) int {
+	if len(issues) == 0 {
+		return resource.GetLineForPath(linePrefix)
+	}
+	return resource.GetLineForPath(issues[0].PropertyPath)
+}
+
 func getSortedKeys(m map[string]config.Severity) []string {
 	keys := make([]string, 0, len(m))
 	for key := range m {
@@ -19,10 +19,13 @@ import (
 	"testing"
 
 	conf "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/kube"
+	"github.com/fairwindsops/polaris/test"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var customCheckExemptions = `
@@ -132,6 +135,11 @@ func TestValidateResourcesPartiallyValid(t *testing.T) {
 			Success:  false,
 			Severity: "warning",
 			Message:  "Memory limits should be within the required range",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources/limits",
+				InvalidValue: map[string]any{"cpu": "200m"},
+				Message:      `"memory" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 	}
@@ -142,6 +150,11 @@ func TestValidateResourcesPartiallyValid(t *testing.T) {
 			Success:  false,
 			Severity: "danger",
 			Message:  "Memory requests should be within the required range",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/resources/requests",
+				InvalidValue: map[string]any{"cpu": "100m"},
+				Message:      `"memory" value is required`,
+			}},
 			Category: "Efficiency",
 		},
 	}
@@ -271,8 +284,218 @@ func TestValidateCustomCheckExemptions(t *testing.T) {
 			Success:  false,
 			Severity: "danger",
 			Message:  "fail!",
+			Details: []ResultDetail{{
+				PropertyPath: "/spec/containers/-1/image",
+				InvalidValue: "hub.docker.com/foo",
+				Message:      "regexp pattern ^quay.io mismatch on string: hub.docker.com/foo",
+			}},
 			Category: "Security",
 		},
 	}
 	testValidate(t, &container, &customCheckExemptions, "notexempt", expectedDangers, expectedWarnings, expectedSuccesses)
 }
+
+func TestResultLinesFromPath(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"deploymentMissingReplicas": conf.SeverityWarning,
+			"cpuLimitsMissing":          conf.SeverityWarning,
+			"pullPolicyNotAlways":       conf.SeverityWarning,
+		},
+	}
+	provider, err := kube.CreateResourceProviderFromPath("../kube/test_files/test_2/multi.yaml")
+	assert.NoError(t, err)
+	deployment := provider.Resources["apps/Deployment"][0]
+	result, err := ApplyAllSchemaChecks(context.Background(), &c, provider, deployment)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "../kube/test_files/test_2/multi.yaml", result.SourceFile)
+	assert.Equal(t, 9, result.SourceLine)
+	assert.Equal(t, 20, result.Results["deploymentMissingReplicas"].Line, "should point at spec.replicas")
+	containerResults := result.PodResult.ContainerResults[0].Results
+	assert.Equal(t, 32, containerResults["cpuLimitsMissing"].Line, "missing fields should point at their parent")
+	assert.Equal(t, []ResultDetail{{
+		PropertyPath: "/spec/template/spec/containers/0/resources",
+		InvalidValue: map[string]any{},
+		Message:      `"limits" value is required`,
+	}}, containerResults["cpuLimitsMissing"].Details)
+	assert.Equal(t, "/spec/replicas: must be greater than or equal to 2 (got 1)", result.Results["deploymentMissingReplicas"].Details[0].String())
+	assert.Equal(t, 0, containerResults["pullPolicyNotAlways"].Line, "passing checks should not have a line")
+}
+
+var customCheckCEL = `
+checks:
+  approvedRegistry: danger
+  resourcesSet: warning
+customChecks:
+  approvedRegistry:
+    successMessage: Image comes from an approved registry
+    failureMessage: Image should come from an approved registry
+    target: Container
+    category: Security
+    cel: container.image.startsWith("quay.io/")
+    celMessage: '"Image " + container.image + " should come from quay.io"'
+  resourcesSet:
+    successMessage: Resources are set
+    failureMessage: Resources should be set
+    target: Container
+    category: Efficiency
+    schema:
+      required:
+      - resources
+    cel: has(container.resources.limits)
+`
+
+func TestValidateCELCheck(t *testing.T) {
+	container := corev1.Container{
+		Name:  "example",
+		Image: "hub.docker.com/foo",
+	}
+	expectedDangers := []ResultMessage{{
+		ID:       "approvedRegistry",
+		Success:  false,
+		Severity: "danger",
+		Message:  "Image hub.docker.com/foo should come from quay.io",
+		Category: "Security",
+	}}
+	expectedWarnings := []ResultMessage{{
+		ID:       "resourcesSet",
+		Success:  false,
+		Severity: "warning",
+		Message:  "Resources should be set",
+		Category: "Efficiency",
+	}}
+	testValidate(t, &container, &customCheckCEL, "foo", expectedDangers, expectedWarnings, []ResultMessage{})
+
+	container.Image = "quay.io/foo"
+	container.Resources.Limits = corev1.ResourceList{"cpu": resource.MustParse("1")}
+	expectedSuccesses := []ResultMessage{{
+		ID:       "approvedRegistry",
+		Success:  true,
+		Severity: "danger",
+		Message:  "Image comes from an approved registry",
+		Category: "Security",
+	}, {
+		ID:       "resourcesSet",
+		Success:  true,
+		Severity: "warning",
+		Message:  "Resources are set",
+		Category: "Efficiency",
+	}}
+	testValidate(t, &container, &customCheckCEL, "foo", []ResultMessage{}, []ResultMessage{}, expectedSuccesses)
+}
+
+func TestValidateCELEvaluationError(t *testing.T) {
+	c, err := conf.Parse([]byte(`
+checks:
+  teamLabel: warning
+customChecks:
+  teamLabel:
+    successMessage: The team label is set
+    failureMessage: The team label should be set
+    target: Controller
+    category: Reliability
+    cel: object.metadata.labels.team != ""
+`))
+	assert.NoError(t, err)
+
+	labeled, pod := test.MockDeploy("test", "labeled")
+	labeled.Labels = map[string]string{"team": "platform"}
+	labeledResource, err := kube.NewGenericResourceFromPod(pod, labeled)
+	assert.NoError(t, err)
+	// Without has(), the expression errors on a resource with no labels
+	unlabeled, pod := test.MockDeploy("test", "unlabeled")
+	unlabeledResource, err := kube.NewGenericResourceFromPod(pod, unlabeled)
+	assert.NoError(t, err)
+
+	results, err := ApplyAllSchemaChecksToAllResources(context.Background(), &c, nil, []kube.GenericResource{labeledResource, unlabeledResource})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.True(t, results[0].Results["teamLabel"].Success)
+		assert.False(t, results[1].Results["teamLabel"].Success)
+		assert.Equal(t, "Error evaluating check: check teamLabel: evaluating cel: no such key: labels", results[1].Results["teamLabel"].Message)
+	}
+}
+
+func TestValidateCELRelatedResources(t *testing.T) {
+	c, err := conf.Parse([]byte(`
+checks:
+  networkPolicyMatches: warning
+customChecks:
+  networkPolicyMatches:
+    successMessage: A NetworkPolicy matches the pod labels
+    failureMessage: A NetworkPolicy should match the pod labels
+    target: PodTemplate
+    category: Security
+    relatedResources:
+    - networking.k8s.io/NetworkPolicy
+    cel: |
+      related["networking.k8s.io/NetworkPolicy"].exists(np,
+        np.spec.podSelector.matchLabels.all(k, k in podTemplate.metadata.labels &&
+          podTemplate.metadata.labels[k] == np.spec.podSelector.matchLabels[k]))
+    celMessage: '"No NetworkPolicy in namespace " + namespaceObject.metadata.name + " matches the pod labels"'
+`))
+	assert.NoError(t, err)
+	provider, err := kube.CreateResourceProviderFromPath("../kube/test_files/test_2/multi.yaml")
+	assert.NoError(t, err)
+	deployment := provider.Resources["apps/Deployment"][0]
+	result, err := ApplyAllSchemaChecks(context.Background(), &c, provider, deployment)
+	assert.NoError(t, err)
+	assert.False(t, result.PodResult.Results["networkPolicyMatches"].Success)
+	assert.Equal(t, "No NetworkPolicy in namespace "+deployment.ObjectMeta.GetNamespace()+" matches the pod labels", result.PodResult.Results["networkPolicyMatches"].Message)
+
+	labels, _ := deployment.PodTemplate.(map[string]any)["metadata"].(map[string]any)["labels"].(map[string]any)
+	provider.Resources["networking.k8s.io/NetworkPolicy"] = []kube.GenericResource{{
+		ObjectMeta: deployment.ObjectMeta,
+		Resource: unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"podSelector": map[string]any{"matchLabels": labels}},
+		}},
+	}}
+	result, err = ApplyAllSchemaChecks(context.Background(), &c, provider, deployment)
+	assert.NoError(t, err)
+	assert.True(t, result.PodResult.Results["networkPolicyMatches"].Success)
+}
+
+func TestParseCELCheck(t *testing.T) {
+	_, err := conf.Parse([]byte("checks:\n  foo: warning\ncustomChecks:\n  foo:\n    target: Container\n    cel: container.image +\n"))
+	assert.ErrorContains(t, err, "check foo: compiling cel")
+	_, err = conf.Parse([]byte("checks:\n  foo: warning\ncustomChecks:\n  foo:\n    target: Container\n    cel: '\"quay.io\"'\n"))
+	assert.EqualError(t, err, "check foo: compiling cel: expression must return a bool, not a string")
+	_, err = conf.Parse([]byte("checks:\n  foo: warning\ncustomChecks:\n  foo:\n    target: Container\n    celMessage: '\"fail\"'\n"))
+	assert.EqualError(t, err, "check foo: celMessage requires cel")
+}
+
+func TestFailingCheckDetailText(t *testing.T) {
+	c := conf.Configuration{
+		Checks: map[string]conf.Severity{
+			"tagNotSpecified":      conf.SeverityDanger,
+			"hostPortSet":          conf.SeverityWarning,
+			"livenessProbeMissing": conf.SeverityWarning,
+			"pullPolicyNotAlways":  conf.SeverityWarning,
+		},
+	}
+	pod := test.MockPod()
+	pod.Spec.Containers[0].Image = "nginx:latest"
+	pod.Spec.Containers[0].ImagePullPolicy = corev1.PullAlways
+	pod.Spec.Containers[0].Ports = []corev1.ContainerPort{{ContainerPort: 8080, HostPort: 443}}
+	workload, err := kube.NewGenericResourceFromPod(pod, nil)
+	assert.NoError(t, err)
+	result, err := ApplyAllSchemaChecks(context.Background(), &c, nil, workload)
+	assert.NoError(t, err)
+
+	detailText := func(id string) []string {
+		var text []string
+		for _, detail := range result.PodResult.ContainerResults[0].Results[id].Details {
+			text = append(text, detail.String())
+		}
+		return text
+	}
+	// Scalar values are included in the text, objects aren't
+	assert.Equal(t, []string{"/spec/containers/0/ports/0/hostPort: must equal 0 (got 443)"}, detailText("hostPortSet"))
+	assert.Equal(t, []string{"/spec/containers/0/image: result was valid, ('not') expected invalid (got nginx:latest)"}, detailText("tagNotSpecified"))
+	assert.Equal(t, []string{`/spec/containers/0: "livenessProbe" value is required`}, detailText("livenessProbeMissing"))
+	assert.Nil(t, detailText("pullPolicyNotAlways"), "passing checks should not have details")
+
+	// Pretty output lists the details under the check's message
+	assert.Contains(t, result.GetPrettyOutput(), "Security - Host port should not be configured\n          /spec/containers/0/ports/0/hostPort: must equal 0 (got 443)\n")
+}
@@ -26,6 +26,8 @@ type CountSummary struct {
 	Successes uint
 	Warnings  uint
 	Dangers   uint
+	// Exempted counts checks that exemptions kept from running. They don't affect the score.
+	Exempted uint `json:",omitempty"`
 }
 
 // CountSummaryByCategory is a map from category to CountSummary
@@ -46,11 +48,14 @@ func (cs *CountSummary) AddSummary(other CountSummary) {
 	cs.Successes += other.Successes
 	cs.Warnings += other.Warnings
 	cs.Dangers += other.Dangers
+	cs.Exempted += other.Exempted
 }
 
 // AddResult adds a single result to the summary
 func (cs *CountSummary) AddResult(result ResultMessage) {
-	if result.Success == false {
+	if result.Exempted {
+		cs.Exempted++
+	} else if result.Success == false {
 		if result.Severity == config.SeverityWarning {
 			cs.Warnings++
 		} else {
@@ -187,7 +192,7 @@ func (a AuditData) GetResultsByNamespace() map[string][]*Result {
 func (rs ResultSet) GetSuccesses() []ResultMessage {
 	successes := []ResultMessage{}
 	for _, msg := range rs {
-		if msg.Success {
+		if msg.Success && !msg.Exempted {
 			successes = append(successes, msg)
 		}
 	}
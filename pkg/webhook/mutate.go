@@ -31,13 +31,14 @@ import (
 
 // Mutator mutate k8s resources.
 type Mutator struct {
-	Client  client.Client
-	Config  config.Configuration
+	Client client.Client
+	// Config holds the current configuration, which may be replaced while the webhook is running
+	Config  *config.Store
 	decoder *admission.Decoder
 }
 
 // NewMutateWebhook creates a mutating admission webhook for the apiType.
-func NewMutateWebhook(ctx context.Context, mgr manager.Manager, c config.Configuration) {
+func NewMutateWebhook(ctx context.Context, mgr manager.Manager, c *config.Store) {
 	path := "/mutate"
 	decoder := admission.NewDecoder(runtime.NewScheme())
 	mutator := Mutator{
@@ -49,7 +50,7 @@ func NewMutateWebhook(ctx context.Context, mgr manager.Manager, c config.Configu
 }
 
 func (m *Mutator) mutate(ctx context.Context, req admission.Request) ([]jsonpatch.Operation, error) {
-	results, kubeResources, err := GetValidatedResults(ctx, req.AdmissionRequest.Kind.Kind, m.decoder, req, m.Config)
+	results, kubeResources, err := GetValidatedResults(ctx, req.AdmissionRequest.Kind.Kind, m.decoder, req, m.Config.Get(), m.Client)
 	if err != nil {
 		logrus.Errorf("Error while validating resource: %v", err)
 		return nil, err
@@ -27,6 +27,7 @@ import (
 	validator "github.com/fairwindsops/polaris/pkg/validator"
 
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -38,11 +39,12 @@ import (
 type Validator struct {
 	Client  client.Client
 	decoder *admission.Decoder
-	Config  config.Configuration
+	// Config holds the current configuration, which may be replaced while the webhook is running
+	Config *config.Store
 }
 
 // NewValidateWebhook creates a validating admission webhook for the apiType.
-func NewValidateWebhook(mgr manager.Manager, c config.Configuration) {
+func NewValidateWebhook(mgr manager.Manager, c *config.Store) {
 	path := "/validate"
 	decoder := admission.NewDecoder(runtime.NewScheme())
 	validator := Validator{
@@ -54,11 +56,12 @@ func NewValidateWebhook(mgr manager.Manager, c config.Configuration) {
 }
 
 func (v *Validator) handleInternal(ctx context.Context, req admission.Request) (*validator.Result, kube.GenericResource, error) {
-	return GetValidatedResults(ctx, req.AdmissionRequest.Kind.Kind, v.decoder, req, v.Config)
+	return GetValidatedResults(ctx, req.AdmissionRequest.Kind.Kind, v.decoder, req, v.Config.Get(), v.Client)
 }
 
-// GetValidatedResults returns the validated results.
-func GetValidatedResults(ctx context.Context, kind string, decoder *admission.Decoder, req admission.Request, config config.Configuration) (*validator.Result, kube.GenericResource, error) {
+// GetValidatedResults returns the validated results. reader is used to look up the resource's Namespace when
+// exemptions or severity overrides select namespaces by label.
+func GetValidatedResults(ctx context.Context, kind string, decoder *admission.Decoder, req admission.Request, config config.Configuration, reader client.Reader) (*validator.Result, kube.GenericResource, error) {
 	var resource kube.GenericResource
 	var err error
 	rawBytes := req.Object.Raw
@@ -107,13 +110,30 @@ func GetValidatedResults(ctx context.Context, kind string, decoder *admission.De
 		logrus.Errorf("Failed to create resource: %v", err)
 		return nil, resource, err
 	}
-	resourceResult, err := validator.ApplyAllSchemaChecks(ctx, &config, nil, resource)
+	resourceProvider, err := getNamespaceProvider(ctx, config, reader, resource.ObjectMeta.GetNamespace())
+	if err != nil {
+		return nil, resource, err
+	}
+	resourceResult, err := validator.ApplyAllSchemaChecks(ctx, &config, resourceProvider, resource)
 	if err != nil {
 		return nil, resource, err
 	}
 	return &resourceResult, resource, nil
 }
 
+// getNamespaceProvider returns a ResourceProvider holding just the resource's Namespace, so that namespaceSelectors
+// in exemptions and severity overrides can match its labels. It's nil when no selector needs them.
+func getNamespaceProvider(ctx context.Context, config config.Configuration, reader client.Reader, namespace string) (*kube.ResourceProvider, error) {
+	if reader == nil || namespace == "" || !config.UsesNamespaceSelectors() {
+		return nil, nil
+	}
+	ns := corev1.Namespace{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		return nil, fmt.Errorf("getting namespace %s: %w", namespace, err)
+	}
+	return &kube.ResourceProvider{Namespaces: []corev1.Namespace{ns}}, nil
+}
+
 // Handle for Validator to run validation checks.
 func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
 	logrus.Info("Starting admission request")
@@ -142,6 +162,7 @@ func getFailureReason(result validator.Result) string {
 	for _, message := range result.Results {
 		if !message.Success && message.Severity == config.SeverityDanger {
 			reason.WriteString(fmt.Sprintf("- %s: %s\n", result.Kind, message.Message))
+			writeFailureDetails(&reason, message)
 		}
 	}
 
@@ -150,6 +171,7 @@ func getFailureReason(result validator.Result) string {
 		for _, message := range podResult.Results {
 			if !message.Success && message.Severity == config.SeverityDanger {
 				reason.WriteString(fmt.Sprintf("- Pod: %s\n", message.Message))
+				writeFailureDetails(&reason, message)
 			}
 		}
 
@@ -157,6 +179,7 @@ func getFailureReason(result validator.Result) string {
 			for _, message := range containerResult.Results {
 				if !message.Success && message.Severity == config.SeverityDanger {
 					reason.WriteString(fmt.Sprintf("- Container %s: %s\n", containerResult.Name, message.Message))
+					writeFailureDetails(&reason, message)
 				}
 			}
 		}
@@ -164,3 +187,9 @@ func getFailureReason(result validator.Result) string {
 
 	return reason.String()
 }
+
+func writeFailureDetails(reason *strings.Builder, message validator.ResultMessage) {
+	for _, detail := range message.Details {
+		reason.WriteString(fmt.Sprintf("    %s\n", detail))
+	}
+}
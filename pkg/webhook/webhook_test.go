@@ -0,0 +1,89 @@
+// Copyright 2022 FairwindsOps, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/test"
+)
+
+func getPodRequest(t *testing.T, namespace string) admission.Request {
+	pod := test.MockPod()
+	pod.Namespace = namespace
+	pod.Spec.HostIPC = true
+	raw, err := json.Marshal(pod)
+	assert.NoError(t, err)
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Namespace: namespace,
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
+func TestValidateNamespaceSelector(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev", Labels: map[string]string{"env": "dev"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"env": "prod"}}},
+	).Build()
+	devSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}}
+
+	tests := []struct {
+		name   string
+		config config.Configuration
+	}{{
+		name: "severity override",
+		config: config.Configuration{
+			Checks: map[string]config.Severity{"hostIPCSet": config.SeverityDanger},
+			SeverityOverrides: []config.SeverityOverride{{
+				NamespaceSelector: devSelector,
+				Checks:            map[string]config.Severity{"hostIPCSet": config.SeverityWarning},
+			}},
+		},
+	}, {
+		name: "exemption",
+		config: config.Configuration{
+			Checks: map[string]config.Severity{"hostIPCSet": config.SeverityDanger},
+			Exemptions: []config.Exemption{{
+				NamespaceSelector: devSelector,
+				Rules:             []string{"hostIPCSet"},
+			}},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Validator{Client: reader, Config: config.NewStore(tt.config)}
+			response := v.Handle(context.Background(), getPodRequest(t, "dev"))
+			assert.True(t, response.Allowed, "the namespace's labels should match the selector")
+			response = v.Handle(context.Background(), getPodRequest(t, "prod"))
+			assert.False(t, response.Allowed)
+			response = v.Handle(context.Background(), getPodRequest(t, "missing"))
+			assert.False(t, response.Allowed)
+			assert.Contains(t, response.Result.Message, "getting namespace missing")
+		})
+	}
+}